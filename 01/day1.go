@@ -1,38 +1,54 @@
-package main
+package day1
 
-import (
-	"fmt"
-	"io/ioutil"
-	"strconv"
-	"strings"
-)
+import "github.com/deosjr/adventofcode2018/scan"
 
-func main() {
-	input, err := ioutil.ReadFile("day1.input")
-	if err != nil {
-		panic(err)
+func parse(input string) ([]int, error) {
+	changes := []int{}
+	r := scan.NewReader(input)
+	for r.Scan() {
+		var n int
+		if err := r.Scanf("%d", &n); err != nil {
+			return nil, err
+		}
+		changes = append(changes, n)
 	}
-	inputList := strings.Split(string(input), "\n")
+	return changes, nil
+}
 
+func part1(changes []int) int {
 	i := 0
-	for _, s := range inputList {
-		n, _ := strconv.Atoi(s)
+	for _, n := range changes {
 		i += n
 	}
-	fmt.Printf("Part 1: %d\n", i)
+	return i
+}
 
-	i = 0
+func part2(changes []int) int {
+	i := 0
 	m := map[int]struct{}{0: struct{}{}}
-Loop:
 	for {
-		for _, s := range inputList {
-			n, _ := strconv.Atoi(s)
+		for _, n := range changes {
 			i += n
 			if _, ok := m[i]; ok {
-				fmt.Printf("Part 2: %d\n", i)
-				break Loop
+				return i
 			}
 			m[i] = struct{}{}
 		}
 	}
 }
+
+func Part1(input string) (interface{}, error) {
+	changes, err := parse(input)
+	if err != nil {
+		return nil, err
+	}
+	return part1(changes), nil
+}
+
+func Part2(input string) (interface{}, error) {
+	changes, err := parse(input)
+	if err != nil {
+		return nil, err
+	}
+	return part2(changes), nil
+}
@@ -0,0 +1,60 @@
+package day1
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseError(t *testing.T) {
+	_, err := parse("+1\n-2\n3x\n")
+	if err == nil {
+		t.Fatal("expected a parse error for line 3")
+	}
+	want := `line 3: cannot parse "3x" as "%d": unexpected trailing text "x"`
+	if err.Error() != want {
+		t.Errorf("got %s want %s", err, want)
+	}
+}
+
+func TestPart1(t *testing.T) {
+	for i, tt := range []struct {
+		input string
+		want  int
+	}{
+		{input: "+1, -2, +3, +1", want: 3},
+		{input: "+1, +1, +1", want: 3},
+		{input: "+1, +1, -2", want: 0},
+		{input: "-1, -2, -3", want: -6},
+	} {
+		changes, err := parse(strings.Replace(tt.input, ", ", "\n", -1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := part1(changes)
+		if got != tt.want {
+			t.Errorf("%d): got %d want %d", i, got, tt.want)
+		}
+	}
+}
+
+func TestPart2(t *testing.T) {
+	for i, tt := range []struct {
+		input string
+		want  int
+	}{
+		{input: "+1, -2, +3, +1", want: 2},
+		{input: "+1, -1", want: 0},
+		{input: "+3, +3, +4, -2, -4", want: 10},
+		{input: "-6, +3, +8, +5, -6", want: 5},
+		{input: "+7, +7, -2, -7, -4", want: 14},
+	} {
+		changes, err := parse(strings.Replace(tt.input, ", ", "\n", -1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := part2(changes)
+		if got != tt.want {
+			t.Errorf("%d): got %d want %d", i, got, tt.want)
+		}
+	}
+}
@@ -1,17 +1,27 @@
-package main
+package day2
 
-import (
-	"fmt"
-	"io/ioutil"
-	"strings"
-)
+import "github.com/deosjr/adventofcode2018/scan"
 
-func main() {
-	input, err := ioutil.ReadFile("day2.input")
-	if err != nil {
-		panic(err)
+// parse checks that all box IDs are lowercase letters and of equal length
+func parse(input string) ([]string, error) {
+	ids := []string{}
+	r := scan.NewReader(input)
+	for r.Scan() {
+		s := r.Text()
+		if len(ids) > 0 && len(s) != len(ids[0]) {
+			return nil, r.Errorf("box ID", "length %d differs from length %d of the first ID", len(s), len(ids[0]))
+		}
+		for _, c := range s {
+			if c < 'a' || c > 'z' {
+				return nil, r.Errorf("box ID", "unexpected character %q", c)
+			}
+		}
+		ids = append(ids, s)
 	}
-	inputList := strings.Split(string(input), "\n")
+	return ids, nil
+}
+
+func part1(inputList []string) int {
 	twos := 0
 	threes := 0
 
@@ -32,8 +42,10 @@ func main() {
 			}
 		}
 	}
-	fmt.Printf("Part 1: %d\n", twos*threes)
+	return twos * threes
+}
 
+func part2(inputList []string) string {
 	var id1, id2 string
 
 	for j, s := range inputList[:len(inputList)] {
@@ -56,6 +68,21 @@ func main() {
 			s += string(id1[i])
 		}
 	}
+	return s
+}
 
-	fmt.Printf("Part 2: %s\n", s)
+func Part1(input string) (interface{}, error) {
+	ids, err := parse(input)
+	if err != nil {
+		return nil, err
+	}
+	return part1(ids), nil
+}
+
+func Part2(input string) (interface{}, error) {
+	ids, err := parse(input)
+	if err != nil {
+		return nil, err
+	}
+	return part2(ids), nil
 }
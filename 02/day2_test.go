@@ -0,0 +1,24 @@
+package day2
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPart1(t *testing.T) {
+	input := []string{"abcdef", "bababc", "abbcde", "abcccd", "aabcdd", "abcdee", "ababab"}
+	got := part1(input)
+	want := 12
+	if got != want {
+		t.Errorf("got %d want %d", got, want)
+	}
+}
+
+func TestPart2(t *testing.T) {
+	input := strings.Split("abcde fghij klmno pqrst fguij axcye wvxyz", " ")
+	got := part2(input)
+	want := "fgij"
+	if got != want {
+		t.Errorf("got %s want %s", got, want)
+	}
+}
@@ -1,61 +1,86 @@
-package main
+package day3
 
 import (
-	"fmt"
-	"io/ioutil"
-	"strconv"
-	"strings"
+	"github.com/deosjr/adventofcode2018/grid"
+	"github.com/deosjr/adventofcode2018/scan"
 )
 
-type coord struct {
-	x, y int
+type claim struct {
+	id         int
+	x, y, w, h int
 }
 
 const overlap = -9999
 
-func main() {
-	input, err := ioutil.ReadFile("day3.input")
-	if err != nil {
-		panic(err)
+func parse(input string) ([]claim, error) {
+	list := []claim{}
+	r := scan.NewReader(input)
+	for r.Scan() {
+		var c claim
+		if err := r.Scanf("#%d @ %d,%d: %dx%d", &c.id, &c.x, &c.y, &c.w, &c.h); err != nil {
+			return nil, err
+		}
+		list = append(list, c)
 	}
+	return list, nil
+}
 
-	m := map[coord]int{}
+// claims returns the total overlapping area and the ids of claims
+// that overlap with at least one other claim
+func claims(list []claim) (int, map[int]struct{}) {
+	fabric := grid.NewSparse[int]()
 	ids := map[int]struct{}{}
 	totalOverlap := 0
-	splitinput := strings.Split(string(input), "\n")
-	for i, claim := range splitinput {
-		s := strings.Split(claim, " ")
-		ss := strings.Split(s[2], ",")
-		x, _ := strconv.Atoi(ss[0])
-		y, _ := strconv.Atoi(ss[1][:len(ss[1])-1])
-		dims := strings.Split(s[3], "x")
-		w, _ := strconv.Atoi(dims[0])
-		h, _ := strconv.Atoi(dims[1])
-
-		id := i + 1
-
-		for yy := y; yy < y+h; yy++ {
-			for xx := x; xx < x+w; xx++ {
-				c := coord{xx, yy}
-				switch m[c] {
+	for _, cl := range list {
+		id := cl.id
+		for yy := cl.y; yy < cl.y+cl.h; yy++ {
+			for xx := cl.x; xx < cl.x+cl.w; xx++ {
+				c := grid.Coord{X: xx, Y: yy}
+				switch fabric.At(c) {
 				case 0:
-					m[c] = id
+					fabric.Set(c, id)
 				case overlap:
 					ids[id] = struct{}{}
 					continue
 				default:
-					ids[m[c]] = struct{}{}
+					ids[fabric.At(c)] = struct{}{}
 					ids[id] = struct{}{}
-					m[c] = overlap
+					fabric.Set(c, overlap)
 					totalOverlap += 1
 				}
 			}
 		}
 	}
-	fmt.Printf("Part 1: %d\n", totalOverlap)
-	for id := 1; id <= len(splitinput); id++ {
-		if _, ok := ids[id]; !ok {
-			fmt.Printf("Part 2: %d\n", id)
+	return totalOverlap, ids
+}
+
+func part1(list []claim) int {
+	totalOverlap, _ := claims(list)
+	return totalOverlap
+}
+
+func part2(list []claim) int {
+	_, ids := claims(list)
+	for _, cl := range list {
+		if _, ok := ids[cl.id]; !ok {
+			return cl.id
 		}
 	}
+	return 0
+}
+
+func Part1(input string) (interface{}, error) {
+	list, err := parse(input)
+	if err != nil {
+		return nil, err
+	}
+	return part1(list), nil
+}
+
+func Part2(input string) (interface{}, error) {
+	list, err := parse(input)
+	if err != nil {
+		return nil, err
+	}
+	return part2(list), nil
 }
@@ -0,0 +1,16 @@
+package day3
+
+import "testing"
+
+func TestClaims(t *testing.T) {
+	input, err := parse("#1 @ 1,3: 4x4\n#2 @ 3,1: 4x4\n#3 @ 5,5: 2x2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := part1(input), 4; got != want {
+		t.Errorf("part1: got %d want %d", got, want)
+	}
+	if got, want := part2(input), 3; got != want {
+		t.Errorf("part2: got %d want %d", got, want)
+	}
+}
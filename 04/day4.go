@@ -1,11 +1,10 @@
-package main
+package day4
 
 import (
-	"fmt"
-	"io/ioutil"
 	"sort"
-	"strconv"
 	"strings"
+
+	"github.com/deosjr/adventofcode2018/scan"
 )
 
 type state uint8
@@ -18,7 +17,7 @@ const (
 
 type log struct {
 	date    string
-	hour    string
+	hour    int
 	minute  int
 	guardID int
 	status  state
@@ -42,41 +41,39 @@ func (s byDate) Less(i, j int) bool {
 	return s[i].date < s[j].date
 }
 
-func main() {
-	input, err := ioutil.ReadFile("day4.input")
-	if err != nil {
-		panic(err)
-	}
-
-	splitinput := strings.Split(string(input), "\n")
-	logs := make([]log, len(splitinput))
-	for i, v := range splitinput {
-		s := strings.Split(v, "]")
-		datetime := strings.Split(s[0][1:], " ")
-		date := datetime[0]
-		clock := strings.Split(datetime[1], ":")
-		hour, min := clock[0], clock[1]
-		minute, _ := strconv.Atoi(min)
-		l := log{
-			date:   date,
-			hour:   hour,
-			minute: minute,
+func parse(input string) ([]log, error) {
+	logs := []log{}
+	r := scan.NewReader(input)
+	for r.Scan() {
+		var l log
+		line := r.Text()
+		i := strings.Index(line, "] ")
+		if i == -1 {
+			return nil, r.Errorf("[yyyy-mm-dd hh:mm] message", "missing timestamp")
+		}
+		if err := scan.Sscanf(line[:i+1], "[%s %d:%d]", &l.date, &l.hour, &l.minute); err != nil {
+			return nil, r.Error("[yyyy-mm-dd hh:mm] message", err)
 		}
-		switch s[1] {
-		case " falls asleep":
+		switch message := line[i+2:]; message {
+		case "falls asleep":
 			l.status = fallsAsleep
-		case " wakes up":
+		case "wakes up":
 			l.status = wakesUp
 		default:
 			l.status = beginsShift
-			split := strings.Split(s[1], "#")
-			furthersplit := strings.Split(split[1], " ")
-			l.guardID, _ = strconv.Atoi(furthersplit[0])
+			if err := scan.Sscanf(message, "Guard #%d begins shift", &l.guardID); err != nil {
+				return nil, r.Error("Guard #%d begins shift", err)
+			}
 		}
-		logs[i] = l
+		logs = append(logs, l)
 	}
 	sort.Sort(byDate(logs))
+	return logs, nil
+}
 
+// sleepSchedule returns total minutes asleep per guard
+// and for each guard how often they were asleep on each minute
+func sleepSchedule(logs []log) (map[int]int, map[int]map[int]int) {
 	totalSleep := map[int]int{}
 	sleepMinsPerGuard := map[int]map[int]int{}
 	var id, min int
@@ -97,12 +94,19 @@ func main() {
 			}
 		}
 	}
+	return totalSleep, sleepMinsPerGuard
+}
 
+func part1(logs []log) int {
+	totalSleep, sleepMinsPerGuard := sleepSchedule(logs)
 	chosenGuard, _ := maxValueWithKey(totalSleep)
 	chosenMinute, _ := maxValueWithKey(sleepMinsPerGuard[chosenGuard])
-	fmt.Printf("Part 1: %d\n", chosenGuard*chosenMinute)
+	return chosenGuard * chosenMinute
+}
 
-	var maxMinute int
+func part2(logs []log) int {
+	_, sleepMinsPerGuard := sleepSchedule(logs)
+	var chosenGuard, chosenMinute, maxMinute int
 	for id, m := range sleepMinsPerGuard {
 		k, v := maxValueWithKey(m)
 		if v >= maxMinute {
@@ -111,7 +115,23 @@ func main() {
 			chosenMinute = k
 		}
 	}
-	fmt.Printf("Part 2: %d\n", chosenGuard*chosenMinute)
+	return chosenGuard * chosenMinute
+}
+
+func Part1(input string) (interface{}, error) {
+	logs, err := parse(input)
+	if err != nil {
+		return nil, err
+	}
+	return part1(logs), nil
+}
+
+func Part2(input string) (interface{}, error) {
+	logs, err := parse(input)
+	if err != nil {
+		return nil, err
+	}
+	return part2(logs), nil
 }
 
 func maxValueWithKey(m map[int]int) (int, int) {
@@ -0,0 +1,36 @@
+package day4
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParts(t *testing.T) {
+	input := `[1518-11-01 00:00] Guard #10 begins shift
+			[1518-11-01 00:05] falls asleep
+			[1518-11-01 00:25] wakes up
+			[1518-11-01 00:30] falls asleep
+			[1518-11-01 00:55] wakes up
+			[1518-11-01 23:58] Guard #99 begins shift
+			[1518-11-02 00:40] falls asleep
+			[1518-11-02 00:50] wakes up
+			[1518-11-03 00:05] Guard #10 begins shift
+			[1518-11-03 00:24] falls asleep
+			[1518-11-03 00:29] wakes up
+			[1518-11-04 00:02] Guard #99 begins shift
+			[1518-11-04 00:36] falls asleep
+			[1518-11-04 00:46] wakes up
+			[1518-11-05 00:03] Guard #99 begins shift
+			[1518-11-05 00:45] falls asleep
+			[1518-11-05 00:55] wakes up`
+	logs, err := parse(strings.Replace(input, "\t", "", -1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := part1(logs), 240; got != want {
+		t.Errorf("part1: got %d want %d", got, want)
+	}
+	if got, want := part2(logs), 4455; got != want {
+		t.Errorf("part2: got %d want %d", got, want)
+	}
+}
@@ -1,55 +1,80 @@
-package main
+package day5
 
 import (
-	"fmt"
-	"io/ioutil"
 	"math"
+
+	"github.com/deosjr/adventofcode2018/scan"
 )
 
-func main() {
-	input, err := ioutil.ReadFile("day5.input")
-	if err != nil {
-		panic(err)
+// parse reads a polymer: a single line of letters
+func parse(input string) ([]byte, error) {
+	r := scan.NewReader(input)
+	if !r.Scan() {
+		return nil, r.Errorf("polymer", "empty input")
+	}
+	polymer := []byte(r.Text())
+	for i, c := range polymer {
+		if !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') {
+			return nil, r.Errorf("polymer", "unexpected character %q at column %d", c, i+1)
+		}
+	}
+	if r.Scan() {
+		return nil, r.Errorf("polymer", "polymer should be a single line")
 	}
-	last := input[0]
-	ans := []byte{last}
-	for _, s := range input[1:] {
+	return polymer, nil
+}
+
+// react fully reacts the polymer, ignoring all units of type c
+// (both polarities) and returns the length of the remaining polymer.
+// pass c = 0 to keep all units
+func react(input []byte, c byte) int {
+	ans := []byte{}
+	for _, s := range input {
+		if c != 0 && (s == c || s == c+32) {
+			continue
+		}
+		if len(ans) == 0 {
+			ans = append(ans, s)
+			continue
+		}
+		last := ans[len(ans)-1]
 		diff := math.Abs(float64(last) - float64(s))
 		if diff != 32 {
 			ans = append(ans, s)
-			last = s
 			continue
 		}
 		ans = ans[:len(ans)-1]
-		last = ans[len(ans)-1]
 	}
-	fmt.Printf("Part 1: %d\n", len(ans))
+	return len(ans)
+}
+
+func part1(input []byte) int {
+	return react(input, 0)
+}
 
+func part2(input []byte) int {
 	shortest := 999999
 	var c byte
 	for c = 65; c <= 90; c++ {
-		last := input[0]
-		ans = []byte{}
-		if last != c && last != c+32 {
-			ans = append(ans, last)
-		}
-		for _, s := range input[1:] {
-			if s == c || s == c+32 {
-				continue
-			}
-			diff := math.Abs(float64(last) - float64(s))
-			if diff != 32 {
-				ans = append(ans, s)
-				last = s
-				continue
-			}
-			ans = ans[:len(ans)-1]
-			last = ans[len(ans)-1]
-		}
-		if len(ans) < shortest {
-			shortest = len(ans)
+		if n := react(input, c); n < shortest {
+			shortest = n
 		}
 	}
+	return shortest
+}
+
+func Part1(input string) (interface{}, error) {
+	polymer, err := parse(input)
+	if err != nil {
+		return nil, err
+	}
+	return part1(polymer), nil
+}
 
-	fmt.Printf("Part 2: %d\n", shortest)
+func Part2(input string) (interface{}, error) {
+	polymer, err := parse(input)
+	if err != nil {
+		return nil, err
+	}
+	return part2(polymer), nil
 }
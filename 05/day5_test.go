@@ -0,0 +1,33 @@
+package day5
+
+import "testing"
+
+func TestReact(t *testing.T) {
+	for i, tt := range []struct {
+		input string
+		c     byte
+		want  int
+	}{
+		{input: "aA", want: 0},
+		{input: "abBA", want: 0},
+		{input: "abAB", want: 4},
+		{input: "aabAAB", want: 6},
+		{input: "dabAcCaCBAcCcaDA", want: 10},
+		{input: "dabAcCaCBAcCcaDA", c: 'A', want: 6},
+		{input: "dabAcCaCBAcCcaDA", c: 'B', want: 8},
+		{input: "dabAcCaCBAcCcaDA", c: 'C', want: 4},
+		{input: "dabAcCaCBAcCcaDA", c: 'D', want: 6},
+	} {
+		got := react([]byte(tt.input), tt.c)
+		if got != tt.want {
+			t.Errorf("%d): got %d want %d", i, got, tt.want)
+		}
+	}
+}
+
+func TestPart2(t *testing.T) {
+	got := part2([]byte("dabAcCaCBAcCcaDA"))
+	if got != 4 {
+		t.Errorf("got %d want %d", got, 4)
+	}
+}
@@ -1,62 +1,45 @@
-package main
+package day6
 
 import (
-	"fmt"
-	"io/ioutil"
-	"math"
-	"strconv"
-	"strings"
+	"github.com/deosjr/adventofcode2018/grid"
+	"github.com/deosjr/adventofcode2018/scan"
 )
 
 // nice way: generate voronoi diagram, compute area of polygons
 // naive / quick and dirty way: calculate manhattan distance to every node for every coordinate..
 // never mind, for part 2 we need to calculate everything anyways!
 
-type coord struct {
-	x, y int
-}
-
-func manhattan(p, q coord) int {
-	xDiff := math.Abs(float64(p.x) - float64(q.x))
-	yDiff := math.Abs(float64(p.y) - float64(q.y))
-	return int(xDiff + yDiff)
-}
-
-func main() {
-	input, err := ioutil.ReadFile("day6.input")
-	if err != nil {
-		panic(err)
-	}
-	splitinput := strings.Split(string(input), "\n")
-
-	var xMax, yMax int
-	coords := make([]coord, len(splitinput))
-	for i, s := range splitinput {
-		split := strings.Split(s, ",")
-		x, _ := strconv.Atoi(split[0])
-		y, _ := strconv.Atoi(strings.TrimSpace(split[1]))
-		coords[i] = coord{x, y}
-		if x > xMax {
-			xMax = x
-		}
-		if y > yMax {
-			yMax = y
+// parse returns the coords and a bounding box from {0,0} that contains them all
+func parse(input string) ([]grid.Coord, grid.Box, error) {
+	bounds := grid.NewBox(grid.Coord{}, grid.Coord{})
+	coords := []grid.Coord{}
+	r := scan.NewReader(input)
+	for r.Scan() {
+		var c grid.Coord
+		if err := r.Scanf("%d, %d", &c.X, &c.Y); err != nil {
+			return nil, grid.Box{}, err
 		}
+		coords = append(coords, c)
+		bounds.Add(c)
 	}
+	return coords, bounds, nil
+}
 
+// areas returns the size of the largest finite area
+// and the size of the region within total distance maxDistance of all coords
+func areas(coords []grid.Coord, bounds grid.Box, maxDistance int) (int, int) {
 	// map of id -> areasize. size -1 means infinite
 	sizes := map[int]int{}
 
-	var c coord
 	var safe, closest, numClosest int
-	// loop over all coordinates from {0,0} to {xMax, yMax} inclusive
-	for y := 0; y <= yMax; y++ {
-		for x := 0; x <= xMax; x++ {
-			c = coord{x, y}
+	// loop over all coordinates in bounds
+	for y := bounds.Min.Y; y <= bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x <= bounds.Max.X; x++ {
+			c := grid.Coord{X: x, Y: y}
 			distance := 99999
 			sum := 0
 			for i, cc := range coords {
-				m := manhattan(c, cc)
+				m := c.Manhattan(cc)
 				sum += m
 				switch {
 				case m < distance:
@@ -69,7 +52,7 @@ func main() {
 					continue
 				}
 			}
-			if sum < 10000 {
+			if sum < maxDistance {
 				safe += 1
 			}
 			if numClosest > 1 {
@@ -78,7 +61,7 @@ func main() {
 			if v, ok := sizes[closest]; ok && v == -1 {
 				continue
 			}
-			if x == 0 || x == xMax || y == 0 || y == yMax {
+			if bounds.OnEdge(c) {
 				sizes[closest] = -1
 				continue
 			}
@@ -87,9 +70,25 @@ func main() {
 	}
 
 	_, ans := maxValueWithKey(sizes)
-	fmt.Printf("Part 1: %d\n", ans)
+	return ans, safe
+}
+
+func Part1(input string) (interface{}, error) {
+	coords, bounds, err := parse(input)
+	if err != nil {
+		return nil, err
+	}
+	largest, _ := areas(coords, bounds, 10000)
+	return largest, nil
+}
 
-	fmt.Printf("Part 2: %d\n", safe)
+func Part2(input string) (interface{}, error) {
+	coords, bounds, err := parse(input)
+	if err != nil {
+		return nil, err
+	}
+	_, safe := areas(coords, bounds, 10000)
+	return safe, nil
 }
 
 // reused from day 3
@@ -0,0 +1,26 @@
+package day6
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAreas(t *testing.T) {
+	input := `1, 1
+			1, 6
+			8, 3
+			3, 4
+			5, 5
+			8, 9`
+	coords, bounds, err := parse(strings.Replace(input, "\t", "", -1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	largest, safe := areas(coords, bounds, 32)
+	if largest != 17 {
+		t.Errorf("largest: got %d want %d", largest, 17)
+	}
+	if safe != 16 {
+		t.Errorf("safe: got %d want %d", safe, 16)
+	}
+}
@@ -1,11 +1,10 @@
-package main
+package day7
 
 import (
-	"fmt"
-	"io/ioutil"
 	"math"
 	"sort"
-	"strings"
+
+	"github.com/deosjr/adventofcode2018/scan"
 )
 
 type node struct {
@@ -42,7 +41,8 @@ func (w worker) idle() bool {
 	return w.node == nil
 }
 
-func part2(nodes []*node, workers []*worker, minute int) int {
+// each step takes baseDuration seconds plus its position in the alphabet
+func part2(nodes []*node, workers []*worker, minute, baseDuration int) int {
 	// check workers are done
 	nextMinute := math.MaxInt64
 	idleWorkers := []*worker{}
@@ -70,7 +70,7 @@ func part2(nodes []*node, workers []*worker, minute int) int {
 		work, tail := nodes[0], nodes[1:]
 		nodes = tail
 		w.node = work
-		w.minDone = minute + int(work.id-64) + 60
+		w.minDone = minute + int(work.id-64) + baseDuration
 		if w.minDone < nextMinute {
 			nextMinute = w.minDone
 		}
@@ -80,7 +80,7 @@ func part2(nodes []*node, workers []*worker, minute int) int {
 	if nextMinute == math.MaxInt64 {
 		return minute
 	}
-	return part2(nodes, workers, nextMinute)
+	return part2(nodes, workers, nextMinute, baseDuration)
 }
 
 func (n *node) removeFromPreReqs(id rune) (isEmpty bool) {
@@ -115,12 +115,15 @@ func addToSorted(nodes []*node, n *node) []*node {
 	return append(nodes[:index], append([]*node{n}, nodes[index:]...)...)
 }
 
-func parseInput(input []string) []*node {
+func parseInput(input string) ([]*node, error) {
 	parseMap := map[rune]*node{}
 	possibleRoots := map[rune]struct{}{}
-	for _, s := range input {
+	r := scan.NewReader(input)
+	for r.Scan() {
 		var prereq, id rune
-		fmt.Sscanf(s, "Step %c must be finished before step %c can begin.", &prereq, &id)
+		if err := r.Scanf("Step %c must be finished before step %c can begin.", &prereq, &id); err != nil {
+			return nil, err
+		}
 		possibleRoots[prereq] = struct{}{}
 		n, ok := parseMap[id]
 		if !ok {
@@ -147,25 +150,26 @@ func parseInput(input []string) []*node {
 			parent.children = append(parent.children, n)
 		}
 	}
-	return roots
+	return roots, nil
 }
 
-func main() {
-	input, err := ioutil.ReadFile("day7.input")
+func Part1(input string) (interface{}, error) {
+	roots, err := parseInput(input)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-	splitinput := strings.Split(string(input), "\n")
-
-	roots := parseInput(splitinput)
-	fmt.Printf("Part 1: %s\n", part1(roots))
+	return part1(roots), nil
+}
 
-	// parse again because we build up a mutable graph
-	roots = parseInput(splitinput)
+func Part2(input string) (interface{}, error) {
+	roots, err := parseInput(input)
+	if err != nil {
+		return nil, err
+	}
 	numWorkers := 5
 	workers := make([]*worker, numWorkers)
 	for i := 0; i < numWorkers; i++ {
 		workers[i] = &worker{}
 	}
-	fmt.Printf("Part 2: %d\n", part2(roots, workers, 0))
+	return part2(roots, workers, 0, 60), nil
 }
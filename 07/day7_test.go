@@ -0,0 +1,37 @@
+package day7
+
+import (
+	"strings"
+	"testing"
+)
+
+var testInput = `Step C must be finished before step A can begin.
+				Step C must be finished before step F can begin.
+				Step A must be finished before step B can begin.
+				Step A must be finished before step D can begin.
+				Step B must be finished before step E can begin.
+				Step D must be finished before step E can begin.
+				Step F must be finished before step E can begin.`
+
+func TestPart1(t *testing.T) {
+	roots, err := parseInput(strings.Replace(testInput, "\t", "", -1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := part1(roots)
+	if got != "CABDFE" {
+		t.Errorf("got %s want %s", got, "CABDFE")
+	}
+}
+
+func TestPart2(t *testing.T) {
+	roots, err := parseInput(strings.Replace(testInput, "\t", "", -1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	workers := []*worker{{}, {}}
+	got := part2(roots, workers, 0, 0)
+	if got != 15 {
+		t.Errorf("got %d want %d", got, 15)
+	}
+}
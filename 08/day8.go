@@ -1,10 +1,10 @@
-package main
+package day8
 
 import (
-	"fmt"
-	"io/ioutil"
 	"strconv"
 	"strings"
+
+	"github.com/deosjr/adventofcode2018/scan"
 )
 
 func part1(input []int) (output []int, answer int) {
@@ -50,21 +50,37 @@ func part2(input []int) (output []int, answer int) {
 	return tail, answer
 }
 
-func main() {
-	input, err := ioutil.ReadFile("day8.input")
-	if err != nil {
-		panic(err)
+func parse(input string) ([]int, error) {
+	r := scan.NewReader(input)
+	if !r.Scan() {
+		return nil, r.Errorf("space separated numbers", "empty input")
 	}
-	splitinput := strings.Split(string(input), " ")
+	splitinput := strings.Split(r.Text(), " ")
 	inputInts := make([]int, len(splitinput))
 	for i, s := range splitinput {
-		parsed, _ := strconv.Atoi(s)
+		parsed, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, r.Error("space separated numbers", err)
+		}
 		inputInts[i] = parsed
 	}
+	return inputInts, nil
+}
 
-	_, answer1 := part1(inputInts)
-	fmt.Printf("Part 1: %d\n", answer1)
+func Part1(input string) (interface{}, error) {
+	tree, err := parse(input)
+	if err != nil {
+		return nil, err
+	}
+	_, answer := part1(tree)
+	return answer, nil
+}
 
-	_, answer2 := part2(inputInts)
-	fmt.Printf("Part 2: %d\n", answer2)
+func Part2(input string) (interface{}, error) {
+	tree, err := parse(input)
+	if err != nil {
+		return nil, err
+	}
+	_, answer := part2(tree)
+	return answer, nil
 }
@@ -0,0 +1,16 @@
+package day8
+
+import "testing"
+
+func TestParts(t *testing.T) {
+	input, err := parse("2 3 0 3 10 11 12 1 1 0 1 99 2 1 1 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, got := part1(input); got != 138 {
+		t.Errorf("part1: got %d want %d", got, 138)
+	}
+	if _, got := part2(input); got != 66 {
+		t.Errorf("part2: got %d want %d", got, 66)
+	}
+}
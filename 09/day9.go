@@ -1,9 +1,6 @@
-package main
+package day9
 
-import (
-	"fmt"
-	"io/ioutil"
-)
+import "github.com/deosjr/adventofcode2018/scan"
 
 type marble struct {
 	number           int
@@ -54,15 +51,25 @@ func marbleGame(numPlayers, numMarbles int) int {
 	return max
 }
 
-func main() {
-	input, err := ioutil.ReadFile("day9.input")
+func parse(input string) (int, int, error) {
+	var numPlayers, numMarbles int
+	r := scan.NewReader(input)
+	err := r.Next("%d players; last marble is worth %d points", &numPlayers, &numMarbles)
+	return numPlayers, numMarbles, err
+}
+
+func Part1(input string) (interface{}, error) {
+	numPlayers, numMarbles, err := parse(input)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-	var numPlayers, numMarbles int
-	fmt.Sscanf(string(input), "%d players; last marble is worth %d points", &numPlayers, &numMarbles)
-	max := marbleGame(numPlayers, numMarbles)
-	fmt.Printf("Part 1: %d\n", max)
-	max = marbleGame(numPlayers, numMarbles*100)
-	fmt.Printf("Part 2: %d\n", max)
+	return marbleGame(numPlayers, numMarbles), nil
+}
+
+func Part2(input string) (interface{}, error) {
+	numPlayers, numMarbles, err := parse(input)
+	if err != nil {
+		return nil, err
+	}
+	return marbleGame(numPlayers, numMarbles*100), nil
 }
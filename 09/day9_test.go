@@ -0,0 +1,26 @@
+package day9
+
+import "testing"
+
+func TestMarbleGame(t *testing.T) {
+	for i, tt := range []struct {
+		input string
+		want  int
+	}{
+		{input: "9 players; last marble is worth 25 points", want: 32},
+		{input: "10 players; last marble is worth 1618 points", want: 8317},
+		{input: "13 players; last marble is worth 7999 points", want: 146373},
+		{input: "17 players; last marble is worth 1104 points", want: 2764},
+		{input: "21 players; last marble is worth 6111 points", want: 54718},
+		{input: "30 players; last marble is worth 5807 points", want: 37305},
+	} {
+		numPlayers, numMarbles, err := parse(tt.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := marbleGame(numPlayers, numMarbles)
+		if got != tt.want {
+			t.Errorf("%d): got %d want %d", i, got, tt.want)
+		}
+	}
+}
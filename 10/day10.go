@@ -1,10 +1,9 @@
-package main
+package day10
 
 import (
-	"fmt"
-	"io/ioutil"
 	"math"
-	"strings"
+
+	"github.com/deosjr/adventofcode2018/scan"
 )
 
 // quick/naive: loop over all seconds and visually inspect the answer		(done)
@@ -104,41 +103,59 @@ func gradientDescent(initial []light, second int, gamma float64, precision, prev
 	return gradientDescent(initial, newSecond, gamma*1.05, precision, stepSize, second, nowBB.product)
 }
 
-func main() {
-	input, err := ioutil.ReadFile("day10.input")
-	if err != nil {
-		panic(err)
-	}
-	splitinput := strings.Split(string(input), "\n")
-
-	lights := make([]light, len(splitinput))
-	for i, s := range splitinput {
+func parse(input string) ([]light, error) {
+	lights := []light{}
+	r := scan.NewReader(input)
+	for r.Scan() {
 		var posX, posY, vX, vY int
-		fmt.Sscanf(s, "position=<%d,%d> velocity=<%d,%d>", &posX, &posY, &vX, &vY)
-		lights[i] = light{posX, posY, vX, vY}
+		if err := r.Scanf("position=<%d,%d> velocity=<%d,%d>", &posX, &posY, &vX, &vY); err != nil {
+			return nil, err
+		}
+		lights = append(lights, light{posX, posY, vX, vY})
 	}
+	return lights, nil
+}
 
+// message returns the lights at the second they spell out a message
+func message(lights []light) (boundingBox, int) {
 	// good gamma found by experimentation :)
 	gamma := 0.004
 	precision := 1
-	bb, second := gradientDescent(lights, 0, gamma, precision, 2, 0, math.MaxInt64)
+	return gradientDescent(lights, 0, gamma, precision, 2, 0, math.MaxInt64)
+}
 
-	fmt.Println("Part1:")
+func (bb boundingBox) print() string {
+	s := ""
 	for y := bb.minY; y <= bb.maxY; y++ {
 	XLoop:
 		for x := bb.minX; x <= bb.maxX; x++ {
 			for _, l := range bb.lights {
 				if l.x == x && l.y == y {
-					fmt.Print("#")
+					s += "#"
 					continue XLoop
 				}
 			}
-			fmt.Print(".")
+			s += "."
 		}
-		fmt.Println()
+		s += "\n"
 	}
+	return s[:len(s)-1]
+}
 
-	fmt.Printf("Part 2: %d\n", second)
+func Part1(input string) (interface{}, error) {
+	lights, err := parse(input)
+	if err != nil {
+		return nil, err
+	}
+	bb, _ := message(lights)
+	return bb.print(), nil
+}
 
-	fmt.Printf("Bonus: evaluated lights state %d times\n", evaluations)
+func Part2(input string) (interface{}, error) {
+	lights, err := parse(input)
+	if err != nil {
+		return nil, err
+	}
+	_, second := message(lights)
+	return second, nil
 }
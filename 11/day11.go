@@ -1,9 +1,10 @@
-package main
+package day11
 
 import (
 	"fmt"
 	"math"
-	"time"
+
+	"github.com/deosjr/adventofcode2018/scan"
 )
 
 const maxSize = 300
@@ -117,18 +118,27 @@ func part2_parallel(serial int) (coord, int) {
 	return ansSoFar.c, ansSoFar.size
 }
 
-func main() {
-	input := 3628
-	ans1 := part1(input)
-	fmt.Printf("Part 1: %d,%d\n", ans1.x, ans1.y)
+func parse(input string) (int, error) {
+	var serial int
+	r := scan.NewReader(input)
+	err := r.Next("%d", &serial)
+	return serial, err
+}
 
-	start := time.Now()
-	ans2, size := part2(input)
-	took := time.Now().Sub(start)
-	fmt.Printf("Part 2: %d,%d,%d ; took %s\n", ans2.x, ans2.y, size, took.String())
+func Part1(input string) (interface{}, error) {
+	serial, err := parse(input)
+	if err != nil {
+		return nil, err
+	}
+	ans := part1(serial)
+	return fmt.Sprintf("%d,%d", ans.x, ans.y), nil
+}
 
-	start = time.Now()
-	ans2, size = part2_parallel(input)
-	took = time.Now().Sub(start)
-	fmt.Printf("Bonus : %d,%d,%d ; took %s\n", ans2.x, ans2.y, size, took.String())
+func Part2(input string) (interface{}, error) {
+	serial, err := parse(input)
+	if err != nil {
+		return nil, err
+	}
+	ans, size := part2(serial)
+	return fmt.Sprintf("%d,%d,%d", ans.x, ans.y, size), nil
 }
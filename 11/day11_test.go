@@ -0,0 +1,54 @@
+package day11
+
+import "testing"
+
+func TestPowerLevel(t *testing.T) {
+	for i, tt := range []struct {
+		x, y, serial int
+		want         int
+	}{
+		{x: 3, y: 5, serial: 8, want: 4},
+		{x: 122, y: 79, serial: 57, want: -5},
+		{x: 217, y: 196, serial: 39, want: 0},
+		{x: 101, y: 153, serial: 71, want: 4},
+	} {
+		got := powerLevel(tt.x, tt.y, tt.serial)
+		if got != tt.want {
+			t.Errorf("%d): got %d want %d", i, got, tt.want)
+		}
+	}
+}
+
+func TestPart1(t *testing.T) {
+	for i, tt := range []struct {
+		serial int
+		want   coord
+	}{
+		{serial: 18, want: coord{33, 45}},
+		{serial: 42, want: coord{21, 61}},
+	} {
+		got := part1(tt.serial)
+		if got != tt.want {
+			t.Errorf("%d): got %v want %v", i, got, tt.want)
+		}
+	}
+}
+
+func TestPart2(t *testing.T) {
+	if testing.Short() {
+		t.Skip("tries every square size")
+	}
+	for i, tt := range []struct {
+		serial int
+		want   coord
+		size   int
+	}{
+		{serial: 18, want: coord{90, 269}, size: 16},
+		{serial: 42, want: coord{232, 251}, size: 12},
+	} {
+		got, size := part2(tt.serial)
+		if got != tt.want || size != tt.size {
+			t.Errorf("%d): got %v,%d want %v,%d", i, got, size, tt.want, tt.size)
+		}
+	}
+}
@@ -1,68 +1,124 @@
-package main
+package day12
 
 import (
 	"fmt"
-	"io/ioutil"
 	"math"
-	"strings"
+	"sort"
+
+	"github.com/deosjr/adventofcode2018/cycle"
+	"github.com/deosjr/adventofcode2018/scan"
 )
 
 type state struct {
 	plants   map[int]bool
 	min, max int
-	shiftSum int
 }
 
-func parse(input string) (state, map[int]bool) {
-	splitinput := strings.Split(input, "\n")
-	initial := strings.Split(splitinput[0], ": ")
+// isPots checks a string only consists of pots with (#) or without (.) plants
+func isPots(s string) bool {
+	for _, c := range s {
+		if c != '#' && c != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+func parse(input string) (state, map[int]bool, error) {
+	r := scan.NewReader(input)
+	var initial string
+	if err := r.Next("initial state: %s", &initial); err != nil {
+		return state{}, nil, err
+	}
+	if !isPots(initial) {
+		return state{}, nil, r.Errorf("initial state: [#.]+", "unexpected character in %q", initial)
+	}
 	initPlants := map[int]bool{}
-	for i, c := range initial[1] {
+	for i, c := range initial {
 		if c == '#' {
 			initPlants[i] = true
 		}
 	}
+	if !r.Scan() || r.Text() != "" {
+		return state{}, nil, r.Errorf("empty line", "expected empty line after initial state")
+	}
 
 	transitions := map[int]bool{}
-	for _, s := range splitinput[2:] {
+	for r.Scan() {
 		// form is: [#.]{5} => [#.]
+		var from, to string
+		if err := r.Scanf("%s => %s", &from, &to); err != nil {
+			return state{}, nil, err
+		}
+		if len(from) != 5 || len(to) != 1 || !isPots(from+to) {
+			return state{}, nil, r.Errorf("[#.]{5} => [#.]", "invalid rule")
+		}
 		sum := 0
 		for i := 0; i < 5; i++ {
-			if s[i] == '#' {
+			if from[i] == '#' {
 				sum += int(math.Exp2(float64(i)))
 			}
 		}
-		if s[9] == '#' {
+		if to == "#" {
 			transitions[sum] = true
 		}
 	}
 	initState := state{
 		plants: initPlants,
 		min:    0,
-		max:    len(initial[1]),
+		max:    len(initial),
 	}
-	return initState, transitions
+	return initState, transitions, nil
 }
 
+// generations returns the sum of the pots with plants after g generations.
+// Eventually the pattern stops changing shape and only moves, so we look
+// for a cycle in its shape and extrapolate how far it moves each cycle.
+// If the shape does not repeat within g generations there is nothing to skip
 func generations(s state, transitions map[int]bool, g int) int {
-	var genSimulated int
-	// simulate until pattern no longer changes, only shifts
-	for genSimulated = 0; genSimulated < g; genSimulated++ {
-		newState := generation(s, transitions)
-		if s.shiftSum == newState.shiftSum {
-			break
+	states := []state{s}
+	c, last, ok := cycle.HashN(s, func(s state) state {
+		s = generation(s, transitions)
+		states = append(states, s)
+		return s
+	}, shape, g)
+	if !ok {
+		return sum(last)
+	}
+	i := c.Index(g)
+	shift := leftmost(states[c.Start+c.Length]) - leftmost(states[c.Start])
+	return sum(states[i]) + len(states[i].plants)*shift*((g-i)/c.Length)
+}
+
+// sum adds up the indices of the pots with plants
+func sum(s state) int {
+	total := 0
+	for k := range s.plants {
+		total += k
+	}
+	return total
+}
+
+// leftmost returns the index of the leftmost plant, 0 if there are none
+func leftmost(s state) int {
+	min, first := 0, true
+	for k := range s.plants {
+		if first || k < min {
+			min, first = k, false
 		}
-		s = newState
 	}
+	return min
+}
 
-	// include shifts in sum
-	// assumption: pattern shifts by one every generation from now
-	genRemaining := g - genSimulated
-	sum := 0
-	for k, _ := range s.plants {
-		sum += k + genRemaining
+// shape lists the plants relative to the leftmost one
+func shape(s state) string {
+	min := leftmost(s)
+	offsets := make([]int, 0, len(s.plants))
+	for k := range s.plants {
+		offsets = append(offsets, k-min)
 	}
-	return sum
+	sort.Ints(offsets)
+	return fmt.Sprint(offsets)
 }
 
 func generation(s state, transitions map[int]bool) state {
@@ -87,16 +143,10 @@ func generation(s state, transitions map[int]bool) state {
 		}
 	}
 
-	shiftSum := 0
-	for k, _ := range newPlants {
-		shiftSum += k - min
-	}
-
 	newState := state{
-		plants:   newPlants,
-		min:      min,
-		max:      max,
-		shiftSum: shiftSum,
+		plants: newPlants,
+		min:    min,
+		max:    max,
 	}
 	return newState
 }
@@ -111,14 +161,18 @@ func plantAt(index int, plants, transitions map[int]bool) bool {
 	return transitions[neighbourhood]
 }
 
-func main() {
-	input, err := ioutil.ReadFile("day12.input")
+func Part1(input string) (interface{}, error) {
+	initial, transitions, err := parse(input)
+	if err != nil {
+		return nil, err
+	}
+	return generations(initial, transitions, 20), nil
+}
+
+func Part2(input string) (interface{}, error) {
+	initial, transitions, err := parse(input)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-	initial, transitions := parse(string(input))
-	part1 := generations(initial, transitions, 20)
-	fmt.Printf("Part 1: %d\n", part1)
-	part2 := generations(initial, transitions, 50000000000)
-	fmt.Printf("Part 2: %d\n", part2)
+	return generations(initial, transitions, 50000000000), nil
 }
@@ -0,0 +1,69 @@
+package day12
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerations(t *testing.T) {
+	input := `initial state: #..#.#..##......###...###
+
+			...## => #
+			..#.. => #
+			.#... => #
+			.#.#. => #
+			.#.## => #
+			.##.. => #
+			.#### => #
+			#.#.# => #
+			#.### => #
+			##.#. => #
+			##.## => #
+			###.. => #
+			###.# => #
+			####. => #`
+	initial, transitions, err := parse(strings.Replace(input, "\t", "", -1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		g    int
+		want int
+	}{
+		{g: 20, want: 325},
+		{g: 50000000000, want: 999999999374},
+	} {
+		got := generations(initial, transitions, tt.g)
+		if got != tt.want {
+			t.Errorf("%d: got %d want %d", tt.g, got, tt.want)
+		}
+	}
+}
+
+// rule 90 grows a Sierpinski triangle, its shape never repeats
+func TestGenerationsWithoutCycle(t *testing.T) {
+	rules := []string{}
+	for n := 0; n < 32; n++ {
+		from := []byte(".....")
+		for i := range from {
+			if n&(1<<i) != 0 {
+				from[i] = '#'
+			}
+		}
+		if from[1] != from[3] {
+			rules = append(rules, string(from)+" => #")
+		}
+	}
+	initial, transitions, err := parse("initial state: #.##\n\n" + strings.Join(rules, "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := initial
+	for i := 0; i < 20; i++ {
+		s = generation(s, transitions)
+	}
+	want := sum(s)
+	if got := generations(initial, transitions, 20); got != want {
+		t.Errorf("got %d want %d", got, want)
+	}
+}
@@ -1,10 +1,10 @@
-package main
+package day13
 
 import (
 	"fmt"
-	"io/ioutil"
-	"sort"
-	"strings"
+
+	"github.com/deosjr/adventofcode2018/grid"
+	"github.com/deosjr/adventofcode2018/scan"
 )
 
 type track uint8
@@ -27,72 +27,72 @@ const (
 	STRAIGHT
 )
 
-type coord struct {
-	x, y int
+func (h heading) String() string {
+	return [...]string{"left", "right", "up", "down", "straight"}[h]
 }
 
+// minecarts are numbered in reading order of the initial map
 type minecart struct {
-	pos        coord
+	id         int
+	pos        grid.Coord
 	heading    heading
 	nextSwitch heading
 }
 
-func parse(filename string) (map[coord]track, []minecart) {
-	input, err := ioutil.ReadFile(filename)
-	if err != nil {
-		panic(err)
-	}
-	splitinput := strings.Split(string(input), "\n")
-
-	tracks := map[coord]track{}
+func parse(input string) (*grid.Sparse[track], []minecart, error) {
+	tracks := grid.NewSparse[track]()
 	minecarts := []minecart{}
-	for y, s := range splitinput {
-		for x, c := range s {
-			pos := coord{x, y}
+	r := scan.NewReader(input)
+	for r.Scan() {
+		y := r.Line() - 1
+		for x, c := range r.Text() {
+			pos := grid.Coord{X: x, Y: y}
 			switch c {
 			// tracks
 			case '-':
-				tracks[pos] = HORIZONTAL
+				tracks.Set(pos, HORIZONTAL)
 			case '|':
-				tracks[pos] = VERTICAL
+				tracks.Set(pos, VERTICAL)
 			case '/':
-				tracks[pos] = SLASH
+				tracks.Set(pos, SLASH)
 			case '\\':
-				tracks[pos] = BACKSLASH
+				tracks.Set(pos, BACKSLASH)
 			case '+':
-				tracks[pos] = CROSSING
+				tracks.Set(pos, CROSSING)
 			// carts
 			case '<':
-				tracks[pos] = HORIZONTAL
-				minecarts = append(minecarts, minecart{pos: pos, heading: LEFT, nextSwitch: LEFT})
+				tracks.Set(pos, HORIZONTAL)
+				minecarts = append(minecarts, minecart{id: len(minecarts), pos: pos, heading: LEFT, nextSwitch: LEFT})
 			case '>':
-				tracks[pos] = HORIZONTAL
-				minecarts = append(minecarts, minecart{pos: pos, heading: RIGHT, nextSwitch: LEFT})
+				tracks.Set(pos, HORIZONTAL)
+				minecarts = append(minecarts, minecart{id: len(minecarts), pos: pos, heading: RIGHT, nextSwitch: LEFT})
 			case '^':
-				tracks[pos] = VERTICAL
-				minecarts = append(minecarts, minecart{pos: pos, heading: UP, nextSwitch: LEFT})
+				tracks.Set(pos, VERTICAL)
+				minecarts = append(minecarts, minecart{id: len(minecarts), pos: pos, heading: UP, nextSwitch: LEFT})
 			case 'v':
-				tracks[pos] = VERTICAL
-				minecarts = append(minecarts, minecart{pos: pos, heading: DOWN, nextSwitch: LEFT})
+				tracks.Set(pos, VERTICAL)
+				minecarts = append(minecarts, minecart{id: len(minecarts), pos: pos, heading: DOWN, nextSwitch: LEFT})
+			case ' ':
+			default:
+				return nil, nil, r.Errorf("track map of [-|/\\+<>^v ]", "unexpected character %q at column %d", c, x+1)
 			}
 		}
 	}
-	return tracks, minecarts
+	return tracks, minecarts, nil
 }
 
-func newPos(pos coord, h heading) coord {
+func newPos(pos grid.Coord, h heading) grid.Coord {
 	switch h {
 	case LEFT:
-		return coord{pos.x - 1, pos.y}
+		return pos.Add(grid.Left)
 	case RIGHT:
-		return coord{pos.x + 1, pos.y}
+		return pos.Add(grid.Right)
 	case UP:
-		return coord{pos.x, pos.y - 1}
+		return pos.Add(grid.Up)
 	case DOWN:
-		return coord{pos.x, pos.y + 1}
+		return pos.Add(grid.Down)
 	}
 	panic("INCORRECT HEADING")
-	return coord{0, 0}
 }
 
 // this can probably be done better by picking smart values for heading enum
@@ -151,26 +151,16 @@ func direction(t track, h, s heading) (heading, heading) {
 		}
 	}
 	panic("INCORRECT HEADING")
-	return 0, 0
 }
 
 func addToSorted(list []minecart, m minecart) []minecart {
-	if len(list) == 0 {
-		return []minecart{m}
-	}
-	// find smallest index for which list[i].pos comes after m.pos in 2d order
-	index := sort.Search(len(list), func(i int) bool { return order2d(m.pos, list[i].pos) })
-	// not found: m.id is biggest so insert last
-	if index == len(list) {
-		return append(list, m)
-	}
-	return append(list[:index], append([]minecart{m}, list[index:]...)...)
+	return grid.Insert(list, m, func(m minecart) grid.Coord { return m.pos })
 }
 
 func removeFromSorted(list []minecart, m minecart) []minecart {
 	toRemove := -1
 	for i, v := range list {
-		if v.pos == m.pos {
+		if v.id == m.id {
 			toRemove = i
 		}
 	}
@@ -187,62 +177,91 @@ func removeFromSorted(list []minecart, m minecart) []minecart {
 	return append(list[:toRemove], list[toRemove+1:]...)
 }
 
-// order2d returns true if q comes after p in 2d grid order
-func order2d(p, q coord) bool {
-	if q.y > p.y {
-		return true
-	}
-	if q.y < p.y {
-		return false
-	}
-	if q.x > p.x {
-		return true
+// Crash is a cart moving onto the square of another one during a tick.
+// Carts[0] is the cart that moved, Carts[1] the one it hit
+type Crash struct {
+	Tick  int
+	Pos   grid.Coord
+	Carts [2]int
+}
+
+// Waypoint is where a cart was after a tick and where it headed from there
+type Waypoint struct {
+	Tick    int
+	Pos     grid.Coord
+	Heading string
+}
+
+// Timeline records a run of the carts: every crash in the order they
+// happened and, for the cart that is left, the path it took.
+// Between two waypoints a cart runs in a straight line.
+type Timeline struct {
+	Ticks    int
+	Crashes  []Crash
+	Survivor int // -1 if no cart is left
+	Path     []Waypoint
+	// turns holds the waypoints of every cart by id
+	turns map[int][]Waypoint
+}
+
+func newTimeline(minecarts []minecart) *Timeline {
+	t := &Timeline{Survivor: -1, turns: map[int][]Waypoint{}}
+	for _, m := range minecarts {
+		t.turn(m.id, m.pos, m.heading)
 	}
-	return false
+	return t
 }
 
-func part1(tracks map[coord]track, minecarts []minecart) coord {
-	for {
-		newMinecarts := make([]minecart, 0, len(minecarts))
-		for i, m := range minecarts {
-			// move the minecart
-			p := newPos(m.pos, m.heading)
+func (t *Timeline) turn(id int, pos grid.Coord, h heading) {
+	t.turns[id] = append(t.turns[id], Waypoint{Tick: t.Ticks, Pos: pos, Heading: h.String()})
+}
 
-			// check collision
-			for _, other := range newMinecarts {
-				if other.pos == p {
-					return p
-				}
-			}
-			if i != len(minecarts)-1 {
-				for _, other := range minecarts[i+1:] {
-					if other.pos == p {
-						return p
-					}
-				}
-			}
+func (t *Timeline) crash(pos grid.Coord, m, other minecart) {
+	t.Crashes = append(t.Crashes, Crash{Tick: t.Ticks, Pos: pos, Carts: [2]int{m.id, other.id}})
+	delete(t.turns, m.id)
+	delete(t.turns, other.id)
+}
 
-			// set new heading/switch based on track
-			newHeading, newSwitch := direction(tracks[p], m.heading, m.nextSwitch)
+// run ticks until at most one cart is left
+func run(tracks *grid.Sparse[track], minecarts []minecart) *Timeline {
+	t := newTimeline(minecarts)
+	for len(minecarts) > 1 {
+		minecarts = t.tick(tracks, minecarts)
+	}
+	if len(minecarts) == 0 {
+		return t
+	}
+	m := minecarts[0]
+	t.Survivor = m.id
+	t.Path = t.turns[m.id]
+	if last := t.Path[len(t.Path)-1]; last.Tick != t.Ticks {
+		t.Path = append(t.Path, Waypoint{Tick: t.Ticks, Pos: m.pos, Heading: m.heading.String()})
+	}
+	return t
+}
 
-			newMinecart := minecart{p, newHeading, newSwitch}
-			newMinecarts = addToSorted(newMinecarts, newMinecart)
-		}
-		minecarts = newMinecarts
+func part1(tracks *grid.Sparse[track], minecarts []minecart) (grid.Coord, error) {
+	if len(minecarts) < 2 {
+		return grid.Coord{}, fmt.Errorf("%d carts can never crash", len(minecarts))
 	}
+	t := newTimeline(minecarts)
+	for len(t.Crashes) == 0 {
+		minecarts = t.tick(tracks, minecarts)
+	}
+	return t.Crashes[0].Pos, nil
 }
 
-func part2(tracks map[coord]track, minecarts []minecart) coord {
-	for {
-		newMinecarts := tick(tracks, minecarts)
-		if len(newMinecarts) == 1 {
-			return newMinecarts[0].pos
-		}
-		minecarts = newMinecarts
+func part2(tracks *grid.Sparse[track], minecarts []minecart) (grid.Coord, error) {
+	t := run(tracks, minecarts)
+	if t.Survivor == -1 {
+		return grid.Coord{}, fmt.Errorf("no cart is left after tick %d", t.Ticks)
 	}
+	return t.Path[len(t.Path)-1].Pos, nil
 }
 
-func tick(tracks map[coord]track, minecarts []minecart) []minecart {
+// tick moves every cart once in reading order, removing the ones that crash
+func (t *Timeline) tick(tracks *grid.Sparse[track], minecarts []minecart) []minecart {
+	t.Ticks++
 	collided := map[int]struct{}{}
 	newMinecarts := make([]minecart, 0, len(minecarts))
 Minecarts:
@@ -259,6 +278,7 @@ Minecarts:
 		for _, other := range newMinecarts {
 			if other.pos == p {
 				newMinecarts = removeFromSorted(newMinecarts, other)
+				t.crash(p, m, other)
 				continue Minecarts
 			}
 		}
@@ -269,26 +289,53 @@ Minecarts:
 				}
 				if other.pos == p {
 					collided[j+i+1] = struct{}{}
+					t.crash(p, m, other)
 					continue Minecarts
 				}
 			}
 		}
 
 		// set new heading/switch based on track
-		newHeading, newSwitch := direction(tracks[p], m.heading, m.nextSwitch)
+		newHeading, newSwitch := direction(tracks.At(p), m.heading, m.nextSwitch)
+		if newHeading != m.heading {
+			t.turn(m.id, p, newHeading)
+		}
 
-		newMinecart := minecart{p, newHeading, newSwitch}
+		newMinecart := minecart{m.id, p, newHeading, newSwitch}
 		newMinecarts = addToSorted(newMinecarts, newMinecart)
 	}
 	return newMinecarts
 }
 
-func main() {
-	tracks, minecarts := parse("day13.input")
+// Run lets the carts crash until at most one is left
+func Run(input string) (*Timeline, error) {
+	tracks, minecarts, err := parse(input)
+	if err != nil {
+		return nil, err
+	}
+	return run(tracks, minecarts), nil
+}
 
-	out1 := part1(tracks, minecarts)
-	fmt.Printf("Part 1: %d,%d\n", out1.x, out1.y)
+func Part1(input string) (interface{}, error) {
+	tracks, minecarts, err := parse(input)
+	if err != nil {
+		return nil, err
+	}
+	out, err := part1(tracks, minecarts)
+	if err != nil {
+		return nil, err
+	}
+	return fmt.Sprintf("%d,%d", out.X, out.Y), nil
+}
 
-	out2 := part2(tracks, minecarts)
-	fmt.Printf("Part 1: %d,%d\n", out2.x, out2.y)
+func Part2(input string) (interface{}, error) {
+	tracks, minecarts, err := parse(input)
+	if err != nil {
+		return nil, err
+	}
+	out, err := part2(tracks, minecarts)
+	if err != nil {
+		return nil, err
+	}
+	return fmt.Sprintf("%d,%d", out.X, out.Y), nil
 }
@@ -0,0 +1,106 @@
+package day13
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/deosjr/adventofcode2018/grid"
+)
+
+func TestPart1(t *testing.T) {
+	input := `/->-\        
+|   |  /----\
+| /-+--+-\  |
+| | |  | v  |
+\-+-/  \-+--/
+  \------/   `
+	tracks, minecarts, err := parse(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := part1(tracks, minecarts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := grid.Coord{X: 7, Y: 3}
+	if got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestPart2(t *testing.T) {
+	input := `/>-<\  
+|   |  
+| /<+-\
+| | | v
+\>+</ |
+  |   ^
+  \<->/`
+	tracks, minecarts, err := parse(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := part2(tracks, minecarts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := grid.Coord{X: 6, Y: 4}
+	if got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestRun(t *testing.T) {
+	for i, tt := range []struct {
+		input    string
+		crashes  []Crash
+		survivor int
+		path     []Waypoint
+	}{
+		{
+			input: `/->-\        
+|   |  /----\
+| /-+--+-\  |
+| | |  | v  |
+\-+-/  \-+--/
+  \------/   `,
+			crashes:  []Crash{{Tick: 14, Pos: grid.Coord{X: 7, Y: 3}, Carts: [2]int{0, 1}}},
+			survivor: -1,
+		},
+		{
+			input: `/>-<\  
+|   |  
+| /<+-\
+| | | v
+\>+</ |
+  |   ^
+  \<->/`,
+			crashes: []Crash{
+				{Tick: 1, Pos: grid.Coord{X: 2, Y: 0}, Carts: [2]int{1, 0}},
+				{Tick: 1, Pos: grid.Coord{X: 2, Y: 4}, Carts: [2]int{5, 4}},
+				{Tick: 1, Pos: grid.Coord{X: 6, Y: 4}, Carts: [2]int{6, 3}},
+				{Tick: 3, Pos: grid.Coord{X: 2, Y: 4}, Carts: [2]int{7, 2}},
+			},
+			survivor: 8,
+			path: []Waypoint{
+				{Tick: 0, Pos: grid.Coord{X: 5, Y: 6}, Heading: "right"},
+				{Tick: 1, Pos: grid.Coord{X: 6, Y: 6}, Heading: "up"},
+				{Tick: 3, Pos: grid.Coord{X: 6, Y: 4}, Heading: "up"},
+			},
+		},
+	} {
+		got, err := Run(tt.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got.Crashes, tt.crashes) {
+			t.Errorf("%d): got crashes %v want %v", i, got.Crashes, tt.crashes)
+		}
+		if got.Survivor != tt.survivor {
+			t.Errorf("%d): got survivor %d want %d", i, got.Survivor, tt.survivor)
+		}
+		if !reflect.DeepEqual(got.Path, tt.path) {
+			t.Errorf("%d): got path %v want %v", i, got.Path, tt.path)
+		}
+	}
+}
@@ -1,8 +1,9 @@
-package main
+package day14
 
 import (
-	"fmt"
 	"math"
+
+	"github.com/deosjr/adventofcode2018/scan"
 )
 
 func part1(after int) int {
@@ -75,10 +76,25 @@ func shift(s, current, input int) (int, bool) {
 	return s, false
 }
 
-func main() {
-	input := 147061
-	out1 := part1(input)
-	fmt.Printf("Part 1: %d\n", out1)
-	out2 := part2(input)
-	fmt.Printf("Part 2: %d\n", out2)
+func parse(input string) (int, error) {
+	var n int
+	r := scan.NewReader(input)
+	err := r.Next("%d", &n)
+	return n, err
+}
+
+func Part1(input string) (interface{}, error) {
+	after, err := parse(input)
+	if err != nil {
+		return nil, err
+	}
+	return part1(after), nil
+}
+
+func Part2(input string) (interface{}, error) {
+	n, err := parse(input)
+	if err != nil {
+		return nil, err
+	}
+	return part2(n), nil
 }
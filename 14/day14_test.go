@@ -0,0 +1,37 @@
+package day14
+
+import "testing"
+
+func TestPart1(t *testing.T) {
+	for i, tt := range []struct {
+		after int
+		want  int
+	}{
+		{after: 9, want: 5158916779},
+		{after: 5, want: 124515891},
+		{after: 18, want: 9251071085},
+		{after: 2018, want: 5941429882},
+	} {
+		got := part1(tt.after)
+		if got != tt.want {
+			t.Errorf("%d): got %d want %d", i, got, tt.want)
+		}
+	}
+}
+
+func TestPart2(t *testing.T) {
+	for i, tt := range []struct {
+		input int
+		want  int
+	}{
+		{input: 515891, want: 9},
+		{input: 12451, want: 5}, // 012451
+		{input: 925107, want: 18},
+		{input: 594142, want: 2018},
+	} {
+		got := part2(tt.input)
+		if got != tt.want {
+			t.Errorf("%d): got %d want %d", i, got, tt.want)
+		}
+	}
+}
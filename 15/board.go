@@ -0,0 +1,143 @@
+package day15
+
+import "github.com/deosjr/adventofcode2018/grid"
+
+// board is the map as a dense grid of tiles, row by row. Anything outside
+// of it, including the end of rows shorter than the longest, is wall
+type board struct {
+	width, height int
+	tiles         []tile
+}
+
+func newBoard(cells map[grid.Coord]tile) board {
+	var b board
+	for c := range cells {
+		b.width = max(b.width, c.X+1)
+		b.height = max(b.height, c.Y+1)
+	}
+	b.tiles = make([]tile, b.width*b.height)
+	for i := range b.tiles {
+		b.tiles[i].isWall = true
+	}
+	for c, t := range cells {
+		b.tiles[b.index(c)] = t
+	}
+	return b
+}
+
+// index returns the index of c in tiles, or -1 if c is not on the board.
+// Indices are in reading order
+func (b board) index(c grid.Coord) int {
+	if c.X < 0 || c.Y < 0 || c.X >= b.width || c.Y >= b.height {
+		return -1
+	}
+	return c.Y*b.width + c.X
+}
+
+func (b board) coord(i int) grid.Coord {
+	return grid.Coord{X: i % b.width, Y: i / b.width}
+}
+
+func (b board) get(c grid.Coord) tile {
+	i := b.index(c)
+	if i < 0 {
+		return tile{isWall: true}
+	}
+	return b.tiles[i]
+}
+
+// set should only be called for coords on the board
+func (b board) set(c grid.Coord, t tile) {
+	b.tiles[b.index(c)] = t
+}
+
+// openNeighbours appends the indices of the tiles next to i that are
+// neither wall nor taken by a unit to buf, in reading order
+func (b board) openNeighbours(i int, buf []int) []int {
+	open := func(j int) bool {
+		return !b.tiles[j].isWall && b.tiles[j].unit == nil
+	}
+	x := i % b.width
+	if i >= b.width && open(i-b.width) {
+		buf = append(buf, i-b.width)
+	}
+	if x > 0 && open(i-1) {
+		buf = append(buf, i-1)
+	}
+	if x < b.width-1 && open(i+1) {
+		buf = append(buf, i+1)
+	}
+	if i+b.width < len(b.tiles) && open(i+b.width) {
+		buf = append(buf, i+b.width)
+	}
+	return buf
+}
+
+// search holds the buffers of a breadth first search over the board,
+// kept from one search to the next. An entry only counts if its stamp
+// is that of the current search, so nothing needs clearing in between
+type search struct {
+	stamp int
+	// seen and inRange hold the stamp of the search that reached a tile
+	// or marked it as a target
+	seen, inRange []int
+	dist          []int
+	// first is the index of the first step on the shortest path to a tile
+	// that comes first in reading order
+	first      []int
+	queue      []int
+	neighbours []int
+}
+
+func (game *gameState) newSearch() *search {
+	if game.search == nil {
+		n := len(game.tiles.tiles)
+		game.search = &search{
+			seen:    make([]int, n),
+			inRange: make([]int, n),
+			dist:    make([]int, n),
+			first:   make([]int, n),
+		}
+	}
+	game.search.stamp++
+	return game.search
+}
+
+// nearest searches outwards from u for the targets marked by
+// possibleTargets, a layer of tiles at equal distance at a time.
+// It returns the nearest target that comes first in reading order and
+// the first step towards it, or false if no target can be reached.
+// The first steps are queued in reading order and every tile takes the
+// first step of the tile it is reached from first, so a tile always gets
+// the first step in reading order over all shortest paths to it
+func (game *gameState) nearest(u Unit) (end, first grid.Coord, ok bool) {
+	b, s := game.tiles, game.search
+	start := b.index(u.Pos())
+	s.seen[start], s.dist[start], s.first[start] = s.stamp, 0, -1
+	s.queue = append(s.queue[:0], start)
+	best := -1
+	for head := 0; head < len(s.queue); head++ {
+		i := s.queue[head]
+		if best >= 0 && s.dist[i] >= s.dist[best] {
+			break
+		}
+		s.neighbours = b.openNeighbours(i, s.neighbours[:0])
+		for _, n := range s.neighbours {
+			if s.seen[n] == s.stamp {
+				continue
+			}
+			s.seen[n], s.dist[n], s.first[n] = s.stamp, s.dist[i]+1, s.first[i]
+			if i == start {
+				s.first[n] = n
+			}
+			s.queue = append(s.queue, n)
+			if s.inRange[n] == s.stamp && (best < 0 || n < best) {
+				best = n
+			}
+		}
+	}
+	if best < 0 {
+		return grid.Coord{}, grid.Coord{}, false
+	}
+	return b.coord(best), b.coord(s.first[best]), true
+}
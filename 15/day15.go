@@ -1,15 +1,12 @@
-package main
+package day15
 
 import (
 	"fmt"
-	"io/ioutil"
-	"sort"
 	"strings"
-)
 
-type coord struct {
-	x, y int
-}
+	"github.com/deosjr/adventofcode2018/grid"
+	"github.com/deosjr/adventofcode2018/scan"
+)
 
 type tile struct {
 	isWall bool
@@ -17,86 +14,132 @@ type tile struct {
 }
 
 type Unit interface {
+	ID() int
 	HP() int
 	Attack() int
-	Pos() coord
+	Pos() grid.Coord
+	Faction() rune
 	Damage(int) bool
 	Enemy(Unit) bool
-	MoveTo(coord)
+	MoveTo(grid.Coord)
 }
 
 type unit struct {
+	// id numbers the units in reading order at the start of the battle
+	id     int
 	hp     int
 	attack int
-	pos    coord
+	// ownAttack is set if the scenario gives this unit an attack power
+	// other than its faction's, which setElfAttack leaves alone
+	ownAttack bool
+	pos       grid.Coord
+	faction   *faction
 }
 
+func (u *unit) ID() int {
+	return u.id
+}
 func (u *unit) HP() int {
 	return u.hp
 }
 func (u *unit) Attack() int {
 	return u.attack
 }
-func (u *unit) Pos() coord {
+func (u *unit) Pos() grid.Coord {
 	return u.pos
 }
+func (u *unit) Faction() rune {
+	return u.faction.symbol
+}
 func (u *unit) Damage(d int) bool {
 	u.hp -= d
 	return u.hp <= 0
 }
-func (u *unit) MoveTo(c coord) {
-	u.pos = c
-}
-
-type elf struct {
-	*unit
-}
-
-func (*elf) Enemy(u Unit) bool {
-	_, ok := u.(*goblin)
-	return ok
+func (u *unit) Enemy(other Unit) bool {
+	return u.faction.enemies[other.Faction()]
 }
-
-type goblin struct {
-	*unit
-}
-
-func (*goblin) Enemy(u Unit) bool {
-	_, ok := u.(*elf)
-	return ok
+func (u *unit) MoveTo(c grid.Coord) {
+	u.pos = c
 }
 
 type gameState struct {
-	tiles map[coord]tile
+	tiles board
 	units []Unit // sorted by pos
-}
-
-func parse(input string) *gameState {
-	tiles := map[coord]tile{}
+	// factions in the order they are declared
+	factions []*faction
+	// rounds is the number of full rounds played so far
+	rounds int
+	// watch, if set, is called after every turn with the unit whose
+	// turn it was and the unit it attacked, if any.
+	// Returning false stops the battle
+	watch func(mover, target Unit) bool
+	// stop, if set, is called with every unit that dies.
+	// Returning true stops the battle
+	stop    func(casualty Unit) bool
+	stopped bool
+	// events, if set, receives everything that happens
+	events Sink
+	search *search
+}
+
+// parse reads a map, optionally preceded by a scenario, see parseScenario
+func parse(input string) (*gameState, error) {
+	r := scan.NewReader(input)
+	sc := defaultScenario()
+	top := 1
+	if isScenario(scan.Lines(input)[0]) {
+		var err error
+		sc, err = parseScenario(r)
+		if err != nil {
+			return nil, err
+		}
+		top = r.Line() + 1
+	}
+	cells := map[grid.Coord]tile{}
 	units := []Unit{}
-	for y, line := range strings.Split(string(input), "\n") {
-		for x, c := range line {
-			pos := coord{x, y}
+	for r.Scan() {
+		y := r.Line() - top
+		for x, c := range r.Text() {
+			pos := grid.Coord{X: x, Y: y}
 			switch c {
 			case '.':
-				tiles[pos] = tile{}
+				cells[pos] = tile{}
 			case '#':
-				tiles[pos] = tile{isWall: true}
-			case 'E':
-				e := &elf{&unit{hp: 200, pos: pos, attack: 3}}
-				units = addToSorted(units, e)
-				tiles[pos] = tile{unit: e}
-			case 'G':
-				g := &goblin{&unit{hp: 200, pos: pos, attack: 3}}
-				units = addToSorted(units, g)
-				tiles[pos] = tile{unit: g}
+				cells[pos] = tile{isWall: true}
+			default:
+				f, ok := sc.factions[c]
+				if !ok {
+					return nil, r.Errorf(sc.mapFormat(), "unexpected character %q at column %d", c, x+1)
+				}
+				u := &unit{id: len(units), hp: f.hp, pos: pos, attack: f.attack, faction: f}
+				units = addToSorted(units, u)
+				cells[pos] = tile{unit: u}
 			}
 		}
 	}
-	return &gameState{
-		tiles: tiles,
-		units: units,
+	for _, o := range sc.overrides {
+		t := cells[o.pos]
+		if t.unit == nil {
+			return nil, &scan.ParseError{Line: o.line, Text: o.text, Expected: "unit x y [hp n] [attack n]",
+				Err: fmt.Errorf("no unit at %d,%d on the map", o.pos.X, o.pos.Y)}
+		}
+		u := t.unit.(*unit)
+		if o.hp > 0 {
+			u.hp = o.hp
+		}
+		if o.attack > 0 {
+			u.attack, u.ownAttack = o.attack, true
+		}
 	}
+	factions := make([]*faction, len(sc.factions))
+	for _, f := range sc.factions {
+		factions[f.index] = f
+	}
+	return &gameState{
+		tiles:    newBoard(cells),
+		units:    units,
+		factions: factions,
+	}, nil
 }
 
 func round(game *gameState) ([]Unit, bool) {
@@ -110,21 +153,13 @@ func round(game *gameState) ([]Unit, bool) {
 			end = true
 			break
 		}
-		t := game.target(u)
-		if t == nil {
-			// move
-			game.move(u, casualties)
-
-			// retarget
-			t = game.target(u)
-			if t == nil {
-				continue
-			}
+		t := game.turn(u, casualties)
+		if game.watch != nil && !game.watch(u, t) {
+			game.stopped = true
 		}
-		//combat
-		if lethal := t.Damage(u.Attack()); lethal {
-			casualties[t] = struct{}{}
-			game.tiles[t.Pos()] = tile{}
+		if game.stopped {
+			end = true
+			break
 		}
 	}
 	newUnits := []Unit{}
@@ -137,36 +172,55 @@ func round(game *gameState) ([]Unit, bool) {
 	return newUnits, end
 }
 
-// adjacent should only be called for non-walls
-// !ok should therefore never occur
-func (game *gameState) adjacentTiles(p coord) []tile {
-	adj := []tile{}
-	neighbours := []coord{{p.x, p.y - 1}, {p.x - 1, p.y}, {p.x + 1, p.y}, {p.x, p.y + 1}}
-	for _, c := range neighbours {
-		t, ok := game.tiles[c]
-		if !ok || t.isWall {
-			continue
+// turn moves u if no enemy is in range, then attacks.
+// It returns the unit attacked, or nil if there was none
+func (game *gameState) turn(u Unit, casualties map[Unit]struct{}) Unit {
+	t := game.target(u)
+	if t == nil {
+		// move
+		from := u.Pos()
+		game.move(u, casualties)
+		if u.Pos() != from {
+			game.emit(Moved{Round: game.rounds + 1, Unit: u.ID(), From: from, To: u.Pos()})
+		}
+
+		// retarget
+		t = game.target(u)
+		if t == nil {
+			return nil
 		}
-		adj = append(adj, t)
 	}
-	return adj
+	//combat
+	lethal := t.Damage(u.Attack())
+	game.emit(Attacked{Round: game.rounds + 1, Unit: u.ID(), Target: t.ID(), Damage: u.Attack(), HP: t.HP()})
+	if lethal {
+		casualties[t] = struct{}{}
+		game.tiles.set(t.Pos(), tile{})
+		game.emit(Died{Round: game.rounds + 1, Unit: t.ID(), At: t.Pos()})
+		if game.stop != nil && game.stop(t) {
+			game.stopped = true
+		}
+	}
+	return t
 }
 
-func (game *gameState) adjacentEmptyCoords(p coord) []coord {
-	coords := []coord{}
-	neighbours := []coord{{p.x, p.y - 1}, {p.x - 1, p.y}, {p.x + 1, p.y}, {p.x, p.y + 1}}
-	for _, c := range neighbours {
-		t, ok := game.tiles[c]
-		if !ok || t.isWall || t.unit != nil {
+func (game *gameState) adjacentTiles(p grid.Coord) []tile {
+	adj := []tile{}
+	for _, c := range p.Neighbours4() {
+		t := game.tiles.get(c)
+		if t.isWall {
 			continue
 		}
-		coords = append(coords, c)
+		adj = append(adj, t)
 	}
-	return coords
+	return adj
 }
 
-func (game *gameState) possibleTargets(u Unit, casualties map[Unit]struct{}) map[coord]struct{} {
-	targetCoords := map[coord]struct{}{}
+// possibleTargets starts a new search and marks the open tiles
+// next to an enemy of u as its targets. It returns how many there are
+func (game *gameState) possibleTargets(u Unit, casualties map[Unit]struct{}) int {
+	s := game.newSearch()
+	n := 0
 	for _, unit := range game.units {
 		if unit == u {
 			continue
@@ -177,91 +231,31 @@ func (game *gameState) possibleTargets(u Unit, casualties map[Unit]struct{}) map
 		if !u.Enemy(unit) {
 			continue
 		}
-		for _, c := range game.adjacentEmptyCoords(unit.Pos()) {
-			targetCoords[c] = struct{}{}
-		}
-	}
-	return targetCoords
-}
-
-func (game *gameState) floodFill(u Unit, targetCoords map[coord]struct{}) (found []coord, floodFillMap map[coord]int) {
-	floodFillMap = map[coord]int{u.Pos(): 0}
-	found = []coord{}
-	i := 0
-	fringe := []coord{u.Pos()}
-	for len(fringe) != 0 {
-		i++
-		newFringe := []coord{}
-
-		for _, f := range fringe {
-			for _, c := range game.adjacentEmptyCoords(f) {
-				if _, ok := floodFillMap[c]; ok {
-					continue
-				}
-				floodFillMap[c] = i
-				newFringe = append(newFringe, c)
-				if _, ok := targetCoords[c]; ok {
-					found = append(found, c)
-				}
+		s.neighbours = game.tiles.openNeighbours(game.tiles.index(unit.Pos()), s.neighbours[:0])
+		for _, c := range s.neighbours {
+			if s.inRange[c] != s.stamp {
+				s.inRange[c] = s.stamp
+				n++
 			}
 		}
-
-		if len(found) > 0 {
-			break
-		}
-		fringe = newFringe
 	}
-	return found, floodFillMap
+	return n
 }
 
 func (game *gameState) move(u Unit, casualties map[Unit]struct{}) {
-	// find possible target locations
-	targetCoords := game.possibleTargets(u, casualties)
-	if len(targetCoords) == 0 {
+	if game.possibleTargets(u, casualties) == 0 {
 		return
 	}
-	// expand to all tiles with increasing stepsize
-	found, floodFillMap := game.floodFill(u, targetCoords)
-	if len(found) == 0 {
+	_, first, ok := game.nearest(u)
+	if !ok {
 		return
 	}
-	// find path to closest target and pick step 1 on the path
-	end := firstInReadingOrder(found)
-	first := game.findFirstStep(end, floodFillMap)
-	// move u to coord first
 	game.step(u, first)
 }
 
-// find ALL shortest paths from end coord to start (score 0)
-// out of those shortest paths, take the first step at reading order
-func (game *gameState) findFirstStep(end coord, ffm map[coord]int) coord {
-	length := ffm[end]
-	paths := map[int]map[coord]struct{}{length: map[coord]struct{}{end: {}}}
-	for i := length - 1; i > 0; i-- {
-		prev := paths[i+1]
-		paths[i] = map[coord]struct{}{}
-		for p, _ := range prev {
-			for _, next := range game.adjacentEmptyCoords(p) {
-				if ffm[next] == i {
-					paths[i][next] = struct{}{}
-				}
-			}
-		}
-	}
-	firsts := []coord{}
-	for k, _ := range paths[1] {
-		firsts = append(firsts, k)
-	}
-	return firstInReadingOrder(firsts)
-}
-
-func (game *gameState) step(u Unit, c coord) {
-	old := game.tiles[c]
-	old.unit = u
-	game.tiles[c] = old
-	told := game.tiles[u.Pos()]
-	told.unit = nil
-	game.tiles[u.Pos()] = told
+func (game *gameState) step(u Unit, c grid.Coord) {
+	game.tiles.set(c, tile{unit: u})
+	game.tiles.set(u.Pos(), tile{})
 	u.MoveTo(c)
 }
 
@@ -282,50 +276,56 @@ func (game *gameState) target(u Unit) Unit {
 			t = tile.unit
 			continue
 		}
-		if order2d(tile.unit.Pos(), t.Pos()) {
+		if tile.unit.Pos().Less(t.Pos()) {
 			t = tile.unit
 		}
 	}
 	return t
 }
 
+// noTargets returns true if no two units left are enemies
 func (game *gameState) noTargets(casualties map[Unit]struct{}) bool {
-	var first Unit = nil
+	// units of the same faction have the same enemies,
+	// so it is enough to check one of each
+	first := map[rune]Unit{}
+	count := map[rune]int{}
 	for _, u := range game.units {
 		if _, ok := casualties[u]; ok {
 			continue
 		}
-		if first == nil {
-			first = u
-			continue
+		if _, ok := first[u.Faction()]; !ok {
+			first[u.Faction()] = u
 		}
-		if first.Enemy(u) {
-			return false
+		count[u.Faction()]++
+	}
+	for f, u := range first {
+		for g, v := range first {
+			if f == g && count[f] == 1 {
+				continue
+			}
+			if u.Enemy(v) {
+				return false
+			}
 		}
 	}
 	return true
 }
 
 func (game *gameState) outcome(rounds int) int {
+	return rounds * game.hp()
+}
+
+// hp returns the hit points left over all units
+func (game *gameState) hp() int {
 	sum := 0
 	for _, u := range game.units {
 		sum += u.HP()
 	}
-	return rounds * sum
+	return sum
 }
 
-// adapted from day 13
 func addToSorted(list []Unit, u Unit) []Unit {
-	if len(list) == 0 {
-		return []Unit{u}
-	}
-	// find smallest index for which list[i].pos comes after u.pos in 2d order
-	index := sort.Search(len(list), func(i int) bool { return order2d(u.Pos(), list[i].Pos()) })
-	// not found: m.id is biggest so insert last
-	if index == len(list) {
-		return append(list, u)
-	}
-	return append(list[:index], append([]Unit{u}, list[index:]...)...)
+	return grid.Insert(list, u, Unit.Pos)
 }
 
 func removeFromSorted(list []unit, u unit) []unit {
@@ -348,77 +348,127 @@ func removeFromSorted(list []unit, u unit) []unit {
 	return append(list[:toRemove], list[toRemove+1:]...)
 }
 
-func firstInReadingOrder(found []coord) coord {
-	t := found[0]
-	// if multiple closest found: pick the closest target in reading order
-	if len(found) > 1 {
-		for _, f := range found[1:] {
-			if order2d(f, t) {
-				t = f
+func part1(game *gameState) int {
+	for {
+		units, noTargetsFound := round(game)
+		game.units = units
+		if noTargetsFound {
+			outcome := game.outcome(game.rounds)
+			if !game.stopped {
+				game.emit(CombatEnded{Rounds: game.rounds, HP: game.hp(), Outcome: outcome})
 			}
+			return outcome
 		}
+		game.rounds++
+		game.emit(RoundEnded{Round: game.rounds})
 	}
-	return t
 }
 
-// order2d returns true if q comes after p in 2d grid order
-func order2d(p, q coord) bool {
-	if q.y > p.y {
-		return true
-	}
-	if q.y < p.y {
-		return false
+// setElfAttack gives every elf the given attack power,
+// except for those with an attack of their own
+func (game *gameState) setElfAttack(power int) {
+	for _, u := range game.units {
+		if isElf(u) && !u.(*unit).ownAttack {
+			u.(*unit).attack = power
+		}
 	}
-	if q.x > p.x {
-		return true
+}
+
+func isElf(u Unit) bool {
+	return u.Faction() == 'E'
+}
+
+// elvesWin plays out the battle with the elves at the given attack power,
+// giving up as soon as an elf dies. It returns the outcome if none did
+func elvesWin(input string, power int) (int, bool, error) {
+	game, err := parse(input)
+	if err != nil {
+		return 0, false, err
 	}
-	return false
+	game.setElfAttack(power)
+	game.stop = isElf
+	outcome := part1(game)
+	return outcome, !game.stopped, nil
 }
 
-func part1(game *gameState) int {
-	rounds := 0
-	for {
-		units, noTargetsFound := round(game)
-		game.units = units
-		if noTargetsFound {
-			return game.outcome(rounds)
+// oneHit returns the attack power at which every hit of an elf kills:
+// the most hit points any unit that is not an elf starts with.
+// Beyond it more power does not change the battle
+func oneHit(input string) (int, error) {
+	game, err := parse(input)
+	if err != nil {
+		return 0, err
+	}
+	// the search below starts at 4
+	power := 4
+	for _, u := range game.units {
+		if !isElf(u) {
+			power = max(power, u.HP())
 		}
-		rounds++
 	}
+	return power, nil
 }
 
-func part2(input string) int {
-	attackPower := 3
+// part2 finds the lowest attack power at which no elf dies, doubling the
+// power until the elves win and then searching between the last power that
+// lost and the first that won. This assumes more power never makes an elf die.
+// The doubling stops at oneHit, where more power does not change the battle
+func part2(input string) (power, outcome int, err error) {
+	oneHit, err := oneHit(input)
+	if err != nil {
+		return 0, 0, err
+	}
+	lost, won := 3, 4
+	outcomes := map[int]int{}
 	for {
-		attackPower++
-		game := parse(input)
-		elfCount := 0
-		for _, u := range game.units {
-			if e, ok := u.(*elf); ok {
-				elfCount++
-				e.unit.attack = attackPower
-			}
+		outcome, ok, err := elvesWin(input, won)
+		if err != nil {
+			return 0, 0, err
 		}
-		outcome := part1(game)
-		for _, u := range game.units {
-			if _, ok := u.(*elf); ok {
-				elfCount--
-			}
+		if ok {
+			outcomes[won] = outcome
+			break
 		}
-		if elfCount == 0 {
-			return outcome
+		if won == oneHit {
+			return 0, 0, fmt.Errorf("an elf dies even at attack power %d", oneHit)
+		}
+		lost, won = won, min(2*won, oneHit)
+	}
+	for won-lost > 1 {
+		mid := (lost + won) / 2
+		outcome, ok, err := elvesWin(input, mid)
+		if err != nil {
+			return 0, 0, err
 		}
+		if ok {
+			won, outcomes[mid] = mid, outcome
+			continue
+		}
+		lost = mid
+	}
+	return won, outcomes[won], nil
+}
+
+// MinimalAttack returns the lowest attack power at which no elf dies
+// and the outcome of the battle at that power
+func MinimalAttack(input string) (int, int, error) {
+	return part2(input)
+}
+
+func Part1(input string) (interface{}, error) {
+	game, err := parse(input)
+	if err != nil {
+		return nil, err
 	}
+	return part1(game), nil
 }
 
-func main() {
-	input, err := ioutil.ReadFile("day15.input")
+func Part2(input string) (interface{}, error) {
+	_, outcome, err := part2(input)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-	game := parse(string(input))
-	fmt.Printf("Part 1: %d\n", part1(game))
-	fmt.Printf("Part 2: %d\n", part2(string(input)))
+	return outcome, nil
 }
 
 func (game *gameState) testPrint(xMax, yMax int, printHealth bool) string {
@@ -426,7 +476,7 @@ func (game *gameState) testPrint(xMax, yMax int, printHealth bool) string {
 	for y := 0; y < yMax; y++ {
 		units := []string{}
 		for x := 0; x < xMax; x++ {
-			t := game.tiles[coord{x, y}]
+			t := game.tiles.get(grid.Coord{X: x, Y: y})
 			if t.unit == nil {
 				if t.isWall {
 					s += "#"
@@ -435,13 +485,8 @@ func (game *gameState) testPrint(xMax, yMax int, printHealth bool) string {
 				s += "."
 				continue
 			}
-			if _, ok := t.unit.(*elf); ok {
-				s += "E"
-				units = append(units, fmt.Sprintf("E(%d)", t.unit.HP()))
-				continue
-			}
-			s += "G"
-			units = append(units, fmt.Sprintf("G(%d)", t.unit.HP()))
+			s += string(t.unit.Faction())
+			units = append(units, fmt.Sprintf("%c(%d)", t.unit.Faction(), t.unit.HP()))
 		}
 		if printHealth && len(units) > 0 {
 			s += "   "
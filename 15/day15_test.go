@@ -1,15 +1,131 @@
-package main
+package day15
 
 import (
 	"reflect"
 	"strconv"
 	"strings"
 	"testing"
+
+	"github.com/deosjr/adventofcode2018/grid"
 )
 
+// battles are the example battles, also used for benchmarks
+var battles = []struct {
+	input string
+	want  int
+}{
+	{
+		input: `#######
+				#.G...#
+				#...EG#
+				#.#.#G#
+				#..G#E#
+				#.....#
+				#######`,
+		want: 27730,
+	},
+	{
+		input: `#######
+				#G..#E#
+				#E#E.E#
+				#G.##.#
+				#...#E#
+				#...E.#
+				#######`,
+		want: 36334,
+	},
+	{
+		input: `#######
+				#E..EG#
+				#.#G.E#
+				#E.##E#
+				#G..#.#
+				#..E#.#
+				#######`,
+		want: 39514,
+	},
+	{
+		input: `#######
+				#E.G#.#
+				#.#G..#
+				#G.#.G#
+				#G..#.#
+				#...E.#
+				#######`,
+		want: 27755,
+	},
+	{
+		input: `#######
+				#.E...#
+				#.#..G#
+				#.###.#
+				#E#G#G#
+				#...#G#
+				#######`,
+		want: 28944,
+	},
+	{
+		input: `#########
+				#G......#
+				#.E.#...#
+				#..##..G#
+				#...##..#
+				#...#...#
+				#.G...G.#
+				#.....G.#
+				#########`,
+		want: 18740,
+	},
+}
+
 func TestPart1(t *testing.T) {
+	for i, tt := range battles {
+		in := strings.Replace(tt.input, "\t", "", -1)
+		game, err := parse(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := part1(game)
+		if got != tt.want {
+			t.Errorf("%d): got %d want %d", i, got, tt.want)
+		}
+	}
+}
+
+func BenchmarkPart1(b *testing.B) {
+	inputs := []string{}
+	for _, tt := range battles {
+		inputs = append(inputs, strings.Replace(tt.input, "\t", "", -1))
+	}
+	for n := 0; n < b.N; n++ {
+		for _, in := range inputs {
+			game, err := parse(in)
+			if err != nil {
+				b.Fatal(err)
+			}
+			part1(game)
+		}
+	}
+}
+
+func BenchmarkPart2(b *testing.B) {
+	inputs := []string{}
+	for _, tt := range battles {
+		inputs = append(inputs, strings.Replace(tt.input, "\t", "", -1))
+	}
+	for n := 0; n < b.N; n++ {
+		for _, in := range inputs {
+			if _, _, err := part2(in); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestPart2(t *testing.T) {
 	for i, tt := range []struct {
 		input string
+		power int
 		want  int
 	}{
 		{
@@ -20,17 +136,8 @@ func TestPart1(t *testing.T) {
 					#..G#E#
 					#.....#
 					#######`,
-			want: 27730,
-		},
-		{
-			input: `#######
-					#G..#E#
-					#E#E.E#
-					#G.##.#
-					#...#E#
-					#...E.#
-					#######`,
-			want: 36334,
+			power: 15,
+			want:  4988,
 		},
 		{
 			input: `#######
@@ -40,7 +147,8 @@ func TestPart1(t *testing.T) {
 					#G..#.#
 					#..E#.#
 					#######`,
-			want: 39514,
+			power: 4,
+			want:  31284,
 		},
 		{
 			input: `#######
@@ -50,7 +158,8 @@ func TestPart1(t *testing.T) {
 					#G..#.#
 					#...E.#
 					#######`,
-			want: 27755,
+			power: 15,
+			want:  3478,
 		},
 		{
 			input: `#######
@@ -60,7 +169,8 @@ func TestPart1(t *testing.T) {
 					#E#G#G#
 					#...#G#
 					#######`,
-			want: 28944,
+			power: 12,
+			want:  6474,
 		},
 		{
 			input: `#########
@@ -72,50 +182,63 @@ func TestPart1(t *testing.T) {
 					#.G...G.#
 					#.....G.#
 					#########`,
-			want: 18740,
+			power: 34,
+			want:  1140,
+		},
+		{
+			// the elf survives four hits, so the goblin with 1000 hp needs more than 200 power
+			input: `unit 2 1 hp 1000 attack 50
+
+					#####
+					#EG.#
+					#####`,
+			power: 250,
+			want:  200,
 		},
 	} {
 		in := strings.Replace(tt.input, "\t", "", -1)
-		game := parse(in)
-		got := part1(game)
-		if got != tt.want {
-			t.Errorf("%d): got %d want %d", i, got, tt.want)
+		power, got, err := part2(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if power != tt.power || got != tt.want {
+			t.Errorf("%d): got power %d outcome %d want %d, %d", i, power, got, tt.power, tt.want)
 		}
 	}
 }
 
 func TestAdjacentTiles(t *testing.T) {
-	e := &elf{&unit{pos: coord{2, 2}}}
-	g := &goblin{&unit{pos: coord{2, 3}}}
+	e := &unit{pos: grid.Coord{X: 2, Y: 2}}
+	g := &unit{pos: grid.Coord{X: 2, Y: 3}}
 	for i, tt := range []struct {
-		tiles map[coord]tile
-		p     coord
+		tiles map[grid.Coord]tile
+		p     grid.Coord
 		want  []tile
 	}{
 		{
-			tiles: map[coord]tile{
-				{2, 2}: tile{unit: e},
-				{1, 2}: tile{},
-				{3, 2}: tile{},
-				{2, 1}: tile{},
-				{2, 3}: tile{},
+			tiles: map[grid.Coord]tile{
+				{X: 2, Y: 2}: tile{unit: e},
+				{X: 1, Y: 2}: tile{},
+				{X: 3, Y: 2}: tile{},
+				{X: 2, Y: 1}: tile{},
+				{X: 2, Y: 3}: tile{},
 			},
-			p:    coord{2, 2},
+			p:    grid.Coord{X: 2, Y: 2},
 			want: []tile{{}, {}, {}, {}},
 		},
 		{
-			tiles: map[coord]tile{
-				{2, 2}: tile{unit: e},
-				{1, 2}: tile{isWall: true},
-				{3, 2}: tile{},
-				{2, 1}: tile{isWall: true},
-				{2, 3}: tile{unit: g},
+			tiles: map[grid.Coord]tile{
+				{X: 2, Y: 2}: tile{unit: e},
+				{X: 1, Y: 2}: tile{isWall: true},
+				{X: 3, Y: 2}: tile{},
+				{X: 2, Y: 1}: tile{isWall: true},
+				{X: 2, Y: 3}: tile{unit: g},
 			},
-			p:    coord{2, 2},
+			p:    grid.Coord{X: 2, Y: 2},
 			want: []tile{{}, {unit: g}},
 		},
 	} {
-		game := gameState{tiles: tt.tiles}
+		game := gameState{tiles: newBoard(tt.tiles)}
 		got := game.adjacentTiles(tt.p)
 		if !reflect.DeepEqual(got, tt.want) {
 			t.Errorf("%d): got %v want %v", i, got, tt.want)
@@ -123,39 +246,42 @@ func TestAdjacentTiles(t *testing.T) {
 	}
 }
 
-func TestAdjacentEmptyCoords(t *testing.T) {
-	e := &elf{&unit{pos: coord{2, 2}}}
-	g := &goblin{&unit{pos: coord{2, 3}}}
+func TestOpenNeighbours(t *testing.T) {
+	e := &unit{pos: grid.Coord{X: 2, Y: 2}}
+	g := &unit{pos: grid.Coord{X: 2, Y: 3}}
 	for i, tt := range []struct {
-		tiles map[coord]tile
-		p     coord
-		want  []coord
+		tiles map[grid.Coord]tile
+		p     grid.Coord
+		want  []grid.Coord
 	}{
 		{
-			tiles: map[coord]tile{
-				{2, 2}: tile{unit: e},
-				{1, 2}: tile{},
-				{3, 2}: tile{},
-				{2, 1}: tile{},
-				{2, 3}: tile{},
+			tiles: map[grid.Coord]tile{
+				{X: 2, Y: 2}: tile{unit: e},
+				{X: 1, Y: 2}: tile{},
+				{X: 3, Y: 2}: tile{},
+				{X: 2, Y: 1}: tile{},
+				{X: 2, Y: 3}: tile{},
 			},
-			p:    coord{2, 2},
-			want: []coord{{2, 1}, {1, 2}, {3, 2}, {2, 3}},
+			p:    grid.Coord{X: 2, Y: 2},
+			want: []grid.Coord{{X: 2, Y: 1}, {X: 1, Y: 2}, {X: 3, Y: 2}, {X: 2, Y: 3}},
 		},
 		{
-			tiles: map[coord]tile{
-				{2, 2}: tile{unit: e},
-				{1, 2}: tile{isWall: true},
-				{3, 2}: tile{},
-				{2, 1}: tile{isWall: true},
-				{2, 3}: tile{unit: g},
+			tiles: map[grid.Coord]tile{
+				{X: 2, Y: 2}: tile{unit: e},
+				{X: 1, Y: 2}: tile{isWall: true},
+				{X: 3, Y: 2}: tile{},
+				{X: 2, Y: 1}: tile{isWall: true},
+				{X: 2, Y: 3}: tile{unit: g},
 			},
-			p:    coord{2, 2},
-			want: []coord{{3, 2}},
+			p:    grid.Coord{X: 2, Y: 2},
+			want: []grid.Coord{{X: 3, Y: 2}},
 		},
 	} {
-		game := gameState{tiles: tt.tiles}
-		got := game.adjacentEmptyCoords(tt.p)
+		b := newBoard(tt.tiles)
+		got := []grid.Coord{}
+		for _, i := range b.openNeighbours(b.index(tt.p), nil) {
+			got = append(got, b.coord(i))
+		}
 		if !reflect.DeepEqual(got, tt.want) {
 			t.Errorf("%d): got %v want %v", i, got, tt.want)
 		}
@@ -255,7 +381,10 @@ func TestMoves(t *testing.T) {
 		},
 	} {
 		in := strings.Replace(tt.input, "\t", "", -1)
-		game := parse(in)
+		game, err := parse(in)
+		if err != nil {
+			t.Fatal(err)
+		}
 		round(game)
 		split := strings.Split(in, "\n")
 		got := game.testPrint(len(split[0]), len(split), false)
@@ -270,7 +399,7 @@ func TestPossibleTargets(t *testing.T) {
 	for i, tt := range []struct {
 		input string
 		unit  int
-		want  []coord
+		want  []grid.Coord
 	}{
 		{
 			input: `#####
@@ -279,7 +408,7 @@ func TestPossibleTargets(t *testing.T) {
 					#...#
 					#####`,
 			unit: 0,
-			want: []coord{},
+			want: []grid.Coord{},
 		},
 		{
 			input: `#####
@@ -288,7 +417,7 @@ func TestPossibleTargets(t *testing.T) {
 					#.E.#
 					#####`,
 			unit: 0,
-			want: []coord{{1, 3}, {3, 3}},
+			want: []grid.Coord{{X: 1, Y: 3}, {X: 3, Y: 3}},
 		},
 		{
 			input: `#####
@@ -297,7 +426,7 @@ func TestPossibleTargets(t *testing.T) {
 					#.E.#
 					#####`,
 			unit: 1,
-			want: []coord{{1, 1}, {3, 1}, {2, 2}},
+			want: []grid.Coord{{X: 1, Y: 1}, {X: 3, Y: 1}, {X: 2, Y: 2}},
 		},
 		{
 			input: `#######
@@ -308,19 +437,28 @@ func TestPossibleTargets(t *testing.T) {
 					#.....#
 					#######`,
 			unit: 1,
-			want: []coord{{1, 1}, {3, 1}, {2, 2}, {5, 1}, {3, 3}, {2, 4}, {3, 5}},
+			want: []grid.Coord{{X: 1, Y: 1}, {X: 3, Y: 1}, {X: 2, Y: 2}, {X: 5, Y: 1}, {X: 3, Y: 3}, {X: 2, Y: 4}, {X: 3, Y: 5}},
 		},
 	} {
 		in := strings.Replace(tt.input, "\t", "", -1)
-		game := parse(in)
+		game, err := parse(in)
+		if err != nil {
+			t.Fatal(err)
+		}
 		unit := game.units[tt.unit]
-		got := game.possibleTargets(unit, map[Unit]struct{}{})
-		wantMap := map[coord]struct{}{}
+		n := game.possibleTargets(unit, map[Unit]struct{}{})
+		got := map[grid.Coord]struct{}{}
+		for j, stamp := range game.search.inRange {
+			if stamp == game.search.stamp {
+				got[game.tiles.coord(j)] = struct{}{}
+			}
+		}
+		wantMap := map[grid.Coord]struct{}{}
 		for _, k := range tt.want {
 			wantMap[k] = struct{}{}
 		}
-		if !reflect.DeepEqual(got, wantMap) {
-			t.Errorf("%d): got %v want %v", i, got, wantMap)
+		if n != len(wantMap) || !reflect.DeepEqual(got, wantMap) {
+			t.Errorf("%d): got %d %v want %v", i, n, got, wantMap)
 		}
 	}
 }
@@ -329,10 +467,10 @@ func TestFloodFill(t *testing.T) {
 	for i, tt := range []struct {
 		input      string
 		unit       int
-		wantFound  []coord
+		wantFound  []grid.Coord
 		wantString string
-		wantFirst  coord
-		wantEnd    *coord
+		wantFirst  grid.Coord
+		wantEnd    *grid.Coord
 	}{
 		{
 			input: `#####
@@ -341,7 +479,7 @@ func TestFloodFill(t *testing.T) {
 					#.E.#
 					#####`,
 			unit:      1,
-			wantFound: []coord{},
+			wantFound: []grid.Coord{},
 			wantString: `#####
 						#.G.#
 						#####
@@ -355,13 +493,13 @@ func TestFloodFill(t *testing.T) {
 					#.E.#
 					#####`,
 			unit:      0,
-			wantFound: []coord{{2, 2}},
+			wantFound: []grid.Coord{{X: 2, Y: 2}},
 			wantString: `#####
 						#101#
 						#.1.#
 						#.E.#
 						#####`,
-			wantFirst: coord{2, 2},
+			wantFirst: grid.Coord{X: 2, Y: 2},
 		},
 		{
 			input: `#######
@@ -370,13 +508,13 @@ func TestFloodFill(t *testing.T) {
 					#...G.#
 					#######`,
 			unit:      0,
-			wantFound: []coord{{4, 2}, {3, 3}},
+			wantFound: []grid.Coord{{X: 4, Y: 2}, {X: 3, Y: 3}},
 			wantString: `#######
 						#10123#
 						#2123.#
 						#323G.#
 						#######`,
-			wantFirst: coord{3, 1},
+			wantFirst: grid.Coord{X: 3, Y: 1},
 		},
 		{
 			input: `#########
@@ -389,7 +527,7 @@ func TestFloodFill(t *testing.T) {
 					#G..G..G#
 					#########`,
 			unit:      4,
-			wantFound: []coord{{4, 2}, {2, 4}, {6, 4}, {4, 6}},
+			wantFound: []grid.Coord{{X: 4, Y: 2}, {X: 2, Y: 4}, {X: 6, Y: 4}, {X: 4, Y: 6}},
 			wantString: `#########
 						#G..G..G#
 						#...2...#
@@ -399,7 +537,7 @@ func TestFloodFill(t *testing.T) {
 						#...2...#
 						#G..G..G#
 						#########`,
-			wantFirst: coord{4, 3},
+			wantFirst: grid.Coord{X: 4, Y: 3},
 		},
 		{
 			input: `######
@@ -410,7 +548,7 @@ func TestFloodFill(t *testing.T) {
 					#..#G#
 					######`,
 			unit:      0,
-			wantFound: []coord{{4, 4}},
+			wantFound: []grid.Coord{{X: 4, Y: 4}},
 			wantString: `######
 						#0123#
 						#12#4#
@@ -418,7 +556,7 @@ func TestFloodFill(t *testing.T) {
 						#3456#
 						#45#G#
 						######`,
-			wantFirst: coord{2, 1},
+			wantFirst: grid.Coord{X: 2, Y: 1},
 		},
 		{
 			input: `######
@@ -429,7 +567,7 @@ func TestFloodFill(t *testing.T) {
 					#..E.#
 					######`,
 			unit:      1,
-			wantFound: []coord{{1, 1}, {2, 2}, {3, 1}},
+			wantFound: []grid.Coord{{X: 1, Y: 1}, {X: 2, Y: 2}, {X: 3, Y: 1}},
 			wantString: `######
 						#6G65#
 						#5654#
@@ -437,7 +575,7 @@ func TestFloodFill(t *testing.T) {
 						#3##2#
 						#2101#
 						######`,
-			wantFirst: coord{2, 5},
+			wantFirst: grid.Coord{X: 2, Y: 5},
 		},
 		{
 			input: `#######
@@ -448,7 +586,7 @@ func TestFloodFill(t *testing.T) {
 					#..#G##
 					#######`,
 			unit:      0,
-			wantFound: []coord{{4, 4}},
+			wantFound: []grid.Coord{{X: 4, Y: 4}},
 			wantString: `#######
 						##0123#
 						#21##4#
@@ -456,7 +594,7 @@ func TestFloodFill(t *testing.T) {
 						#45676#
 						#56#G##
 						#######`,
-			wantFirst: coord{3, 1},
+			wantFirst: grid.Coord{X: 3, Y: 1},
 		},
 		{
 			input: `#########
@@ -469,7 +607,7 @@ func TestFloodFill(t *testing.T) {
 					#.......#
 					#########`,
 			unit:      6,
-			wantFound: []coord{{4, 4}},
+			wantFound: []grid.Coord{{X: 4, Y: 4}},
 			wantString: `#########
 						#4......#
 						#34GGG..#
@@ -479,7 +617,7 @@ func TestFloodFill(t *testing.T) {
 						#1234...#
 						#234....#
 						#########`,
-			wantFirst: coord{1, 4},
+			wantFirst: grid.Coord{X: 1, Y: 4},
 		},
 		{
 			input: `.G..
@@ -487,13 +625,13 @@ func TestFloodFill(t *testing.T) {
 					...E
 					E...`,
 			unit:      0,
-			wantFound: []coord{{2, 2}, {0, 2}, {1, 3}},
+			wantFound: []grid.Coord{{X: 2, Y: 2}, {X: 0, Y: 2}, {X: 1, Y: 3}},
 			wantString: `1012
 						#12#
 						323E
 						E3..`,
-			wantFirst: coord{1, 1},
-			wantEnd:   &coord{0, 2},
+			wantFirst: grid.Coord{X: 1, Y: 1},
+			wantEnd:   &grid.Coord{X: 0, Y: 2},
 		},
 		{
 			input: `..G...#.##.#
@@ -504,7 +642,7 @@ func TestFloodFill(t *testing.T) {
 					#...EGE.#..#
 					.E.........#`,
 			unit:      0,
-			wantFound: []coord{{1, 5}, {5, 3}, {4, 4}, {3, 5}, {2, 6}},
+			wantFound: []grid.Coord{{X: 1, Y: 5}, {X: 5, Y: 3}, {X: 4, Y: 4}, {X: 3, Y: 5}, {X: 2, Y: 6}},
 			wantString: `210123#.##.#
 						#2123GGE...#
 						#323456.#..#
@@ -512,18 +650,28 @@ func TestFloodFill(t *testing.T) {
 						#5456E.....#
 						#656EGE.#..#
 						.E6........#`,
-			wantFirst: coord{3, 0},
+			wantFirst: grid.Coord{X: 3, Y: 0},
 		},
 	} {
 		in := strings.Replace(tt.input, "\t", "", -1)
-		game := parse(in)
+		game, err := parse(in)
+		if err != nil {
+			t.Fatal(err)
+		}
 		unit := game.units[tt.unit]
-		gotFound, gotMap := game.floodFill(unit, game.possibleTargets(unit, map[Unit]struct{}{}))
-		if !reflect.DeepEqual(gotFound, tt.wantFound) {
-			t.Errorf("%d): got %v want %v", i, gotFound, tt.wantFound)
+		game.possibleTargets(unit, map[Unit]struct{}{})
+		gotEnd, gotFirst, ok := game.nearest(unit)
+		if ok != (len(tt.wantFound) > 0) {
+			t.Errorf("%d): got found %t want %v", i, ok, tt.wantFound)
+		}
+		gotMap := map[grid.Coord]int{}
+		for j, stamp := range game.search.seen {
+			if stamp == game.search.stamp {
+				gotMap[game.tiles.coord(j)] = game.search.dist[j]
+			}
 		}
 		// parse wantString into floodfillmap
-		wantMap := map[coord]int{}
+		wantMap := map[grid.Coord]int{}
 		split := strings.Split(tt.wantString, "\n")
 		for y := 0; y < len(split); y++ {
 			s := strings.Replace(split[y], "\t", "", -1)
@@ -532,7 +680,7 @@ func TestFloodFill(t *testing.T) {
 				if err != nil {
 					continue
 				}
-				wantMap[coord{x, y}] = i
+				wantMap[grid.Coord{X: x, Y: y}] = i
 			}
 		}
 		if !reflect.DeepEqual(gotMap, wantMap) {
@@ -541,44 +689,20 @@ func TestFloodFill(t *testing.T) {
 		if len(tt.wantFound) == 0 {
 			continue
 		}
-		gotEnd := firstInReadingOrder(gotFound)
+		// the nearest target found first in reading order
+		wantEnd := grid.First(tt.wantFound)
 		if tt.wantEnd != nil {
-			if gotEnd != *tt.wantEnd {
-				t.Errorf("%d): got %v want %v", i, gotEnd, *tt.wantEnd)
-			}
+			wantEnd = *tt.wantEnd
+		}
+		if gotEnd != wantEnd {
+			t.Errorf("%d): got %v want %v", i, gotEnd, wantEnd)
 		}
-		gotFirst := game.findFirstStep(gotEnd, gotMap)
 		if gotFirst != tt.wantFirst {
 			t.Errorf("%d): got %v want %v", i, gotFirst, tt.wantFirst)
 		}
 	}
 }
 
-func TestFirstInReadingOrder(t *testing.T) {
-	for i, tt := range []struct {
-		coords []coord
-		want   coord
-	}{
-		{
-			coords: []coord{{0, 0}},
-			want:   coord{0, 0},
-		},
-		{
-			coords: []coord{{4, 2}, {1, 0}, {2, 3}},
-			want:   coord{1, 0},
-		},
-		{
-			coords: []coord{{4, 2}, {3, 3}},
-			want:   coord{4, 2},
-		},
-	} {
-		got := firstInReadingOrder(tt.coords)
-		if got != tt.want {
-			t.Errorf("%d): got %v want %v", i, got, tt.want)
-		}
-	}
-}
-
 func TestRoundWithCombat(t *testing.T) {
 	for i, tt := range []struct {
 		input string
@@ -686,14 +810,12 @@ func TestRoundWithCombat(t *testing.T) {
 		},
 	} {
 		in := strings.Replace(tt.input, "\t", "", -1)
-		game := parse(in)
+		game, err := parse(in)
+		if err != nil {
+			t.Fatal(err)
+		}
 		for k, v := range tt.setHP {
-			u := game.units[k]
-			if e, ok := u.(*elf); ok {
-				e.hp = v
-				continue
-			}
-			u.(*goblin).hp = v
+			game.units[k].(*unit).hp = v
 		}
 		round(game)
 		split := strings.Split(in, "\n")
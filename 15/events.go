@@ -0,0 +1,321 @@
+package day15
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/deosjr/adventofcode2018/grid"
+)
+
+// Event is something that happens during a battle. Units are referred to
+// by their ID, their index in reading order at the start of the battle.
+// Rounds count from 1
+type Event interface {
+	// eventType names the event in the JSON lines log
+	eventType() string
+}
+
+// Moved is a unit taking a step
+type Moved struct {
+	Round int        `json:"round"`
+	Unit  int        `json:"unit"`
+	From  grid.Coord `json:"from"`
+	To    grid.Coord `json:"to"`
+}
+
+// Attacked is a unit hitting another, leaving it with HP hit points
+type Attacked struct {
+	Round  int `json:"round"`
+	Unit   int `json:"unit"`
+	Target int `json:"target"`
+	Damage int `json:"damage"`
+	HP     int `json:"hp"`
+}
+
+// Died is a unit being removed from the map
+type Died struct {
+	Round int        `json:"round"`
+	Unit  int        `json:"unit"`
+	At    grid.Coord `json:"at"`
+}
+
+// RoundEnded is every unit having taken its turn
+type RoundEnded struct {
+	Round int `json:"round"`
+}
+
+// CombatEnded is a unit finding no targets left. Rounds is the number
+// of full rounds played and HP the hit points left over all units
+type CombatEnded struct {
+	Rounds  int `json:"rounds"`
+	HP      int `json:"hp"`
+	Outcome int `json:"outcome"`
+}
+
+func (Moved) eventType() string       { return "moved" }
+func (Attacked) eventType() string    { return "attacked" }
+func (Died) eventType() string        { return "died" }
+func (RoundEnded) eventType() string  { return "round ended" }
+func (CombatEnded) eventType() string { return "combat ended" }
+
+func (e Moved) String() string {
+	return fmt.Sprintf("round %d: unit %d moved from %v to %v", e.Round, e.Unit, e.From, e.To)
+}
+func (e Attacked) String() string {
+	return fmt.Sprintf("round %d: unit %d attacked unit %d for %d damage, leaving %d hp", e.Round, e.Unit, e.Target, e.Damage, e.HP)
+}
+func (e Died) String() string {
+	return fmt.Sprintf("round %d: unit %d died at %v", e.Round, e.Unit, e.At)
+}
+func (e RoundEnded) String() string {
+	return fmt.Sprintf("round %d ended", e.Round)
+}
+func (e CombatEnded) String() string {
+	return fmt.Sprintf("combat ended after %d full rounds with %d hp left: outcome %d", e.Rounds, e.HP, e.Outcome)
+}
+
+// Sink receives the events of a battle as they happen
+type Sink interface {
+	Emit(Event)
+}
+
+// Log is a sink that keeps every event
+type Log []Event
+
+func (l *Log) Emit(e Event) {
+	*l = append(*l, e)
+}
+
+func (game *gameState) emit(e Event) {
+	if game.events != nil {
+		game.events.Emit(e)
+	}
+}
+
+// Battle plays out the battle like Watch does, sending every event
+// to sink, and returns the outcome
+func Battle(input string, elfAttack int, sink Sink) (int, error) {
+	return Watch(input, elfAttack, func(Turn) bool { return true }, sink)
+}
+
+// line is how an event is stored in a JSON lines log
+type line struct {
+	Type  string          `json:"type"`
+	Event json.RawMessage `json:"event"`
+}
+
+// JSONLines is a sink writing every event as a JSON object on its own line.
+// It stops at the first error, which Err returns
+type JSONLines struct {
+	enc *json.Encoder
+	err error
+}
+
+func NewJSONLines(w io.Writer) *JSONLines {
+	return &JSONLines{enc: json.NewEncoder(w)}
+}
+
+func (j *JSONLines) Emit(e Event) {
+	if j.err != nil {
+		return
+	}
+	raw, err := json.Marshal(e)
+	if err != nil {
+		j.err = err
+		return
+	}
+	j.err = j.enc.Encode(line{Type: e.eventType(), Event: raw})
+}
+
+func (j *JSONLines) Err() error {
+	return j.err
+}
+
+// ReadJSONLines reads back the events written by JSONLines
+func ReadJSONLines(r io.Reader) ([]Event, error) {
+	events := []Event{}
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		var l line
+		if err := json.Unmarshal(s.Bytes(), &l); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		var err error
+		switch l.Type {
+		case "moved":
+			var e Moved
+			err = json.Unmarshal(l.Event, &e)
+			events = append(events, e)
+		case "attacked":
+			var e Attacked
+			err = json.Unmarshal(l.Event, &e)
+			events = append(events, e)
+		case "died":
+			var e Died
+			err = json.Unmarshal(l.Event, &e)
+			events = append(events, e)
+		case "round ended":
+			var e RoundEnded
+			err = json.Unmarshal(l.Event, &e)
+			events = append(events, e)
+		case "combat ended":
+			var e CombatEnded
+			err = json.Unmarshal(l.Event, &e)
+			events = append(events, e)
+		default:
+			err = fmt.Errorf("unknown event type %q", l.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+	}
+	return events, s.Err()
+}
+
+// Replay applies a log of events to the map in input, setting up the elves
+// with elfAttack like Watch does. It checks that every event fits the
+// battle so far and returns the outcome the log ends with
+func Replay(input string, elfAttack int, events []Event) (int, error) {
+	game, err := parse(input)
+	if err != nil {
+		return 0, err
+	}
+	if elfAttack > 0 {
+		game.setElfAttack(elfAttack)
+	}
+	alive := map[int]Unit{}
+	for _, u := range game.units {
+		alive[u.ID()] = u
+	}
+	unit := func(id int) (Unit, error) {
+		u, ok := alive[id]
+		if !ok {
+			return nil, fmt.Errorf("no unit %d on the map", id)
+		}
+		return u, nil
+	}
+	// dying is the unit left without hit points by the last attack,
+	// whose death has to be the next event
+	dying := -1
+	died := func() error {
+		if dying >= 0 {
+			return fmt.Errorf("expected unit %d to die", dying)
+		}
+		return nil
+	}
+	rounds := 0
+	for i, e := range events {
+		err := func() error {
+			if _, ok := e.(Attacked); !ok {
+				if d, ok := e.(Died); !ok || d.Unit != dying {
+					if err := died(); err != nil {
+						return err
+					}
+				}
+			}
+			switch e := e.(type) {
+			case Moved:
+				if e.Round != rounds+1 {
+					return fmt.Errorf("expected round %d", rounds+1)
+				}
+				u, err := unit(e.Unit)
+				if err != nil {
+					return err
+				}
+				if u.Pos() != e.From {
+					return fmt.Errorf("unit %d is at %v", e.Unit, u.Pos())
+				}
+				if e.From.Manhattan(e.To) != 1 {
+					return fmt.Errorf("%v is not next to %v", e.To, e.From)
+				}
+				if t := game.tiles.get(e.To); t.isWall || t.unit != nil {
+					return fmt.Errorf("%v is not empty", e.To)
+				}
+				game.step(u, e.To)
+			case Attacked:
+				if e.Round != rounds+1 {
+					return fmt.Errorf("expected round %d", rounds+1)
+				}
+				u, err := unit(e.Unit)
+				if err != nil {
+					return err
+				}
+				t, err := unit(e.Target)
+				if err != nil {
+					return err
+				}
+				for _, v := range []Unit{u, t} {
+					if v.HP() <= 0 {
+						return fmt.Errorf("unit %d is dead", v.ID())
+					}
+				}
+				if err := died(); err != nil {
+					return err
+				}
+				if !u.Enemy(t) || u.Pos().Manhattan(t.Pos()) != 1 {
+					return fmt.Errorf("unit %d is not an enemy next to unit %d", e.Target, e.Unit)
+				}
+				if e.Damage != u.Attack() {
+					return fmt.Errorf("unit %d attacks for %d damage", e.Unit, u.Attack())
+				}
+				if t.Damage(e.Damage) {
+					dying = e.Target
+				}
+				if t.HP() != e.HP {
+					return fmt.Errorf("unit %d has %d hp left", e.Target, t.HP())
+				}
+			case Died:
+				if e.Round != rounds+1 {
+					return fmt.Errorf("expected round %d", rounds+1)
+				}
+				u, err := unit(e.Unit)
+				if err != nil {
+					return err
+				}
+				if u.HP() > 0 || u.Pos() != e.At {
+					return fmt.Errorf("unit %d is at %v with %d hp", e.Unit, u.Pos(), u.HP())
+				}
+				game.tiles.set(u.Pos(), tile{})
+				delete(alive, e.Unit)
+				dying = -1
+			case RoundEnded:
+				if e.Round != rounds+1 {
+					return fmt.Errorf("expected round %d", rounds+1)
+				}
+				rounds++
+			case CombatEnded:
+				hp := 0
+				units := []Unit{}
+				for _, u := range alive {
+					hp += u.HP()
+					units = append(units, u)
+				}
+				game.units = units
+				if !game.noTargets(nil) {
+					return fmt.Errorf("both sides have units left")
+				}
+				if e.Rounds != rounds || e.HP != hp || e.Outcome != rounds*hp {
+					return fmt.Errorf("expected %d full rounds with %d hp left", rounds, hp)
+				}
+				if i != len(events)-1 {
+					return fmt.Errorf("more events follow")
+				}
+			}
+			return nil
+		}()
+		if err != nil {
+			return 0, fmt.Errorf("event %d, %v: %w", i+1, e, err)
+		}
+	}
+	var end CombatEnded
+	ok := false
+	if len(events) > 0 {
+		end, ok = events[len(events)-1].(CombatEnded)
+	}
+	if !ok {
+		return 0, fmt.Errorf("log ends before the combat does")
+	}
+	return end.Outcome, nil
+}
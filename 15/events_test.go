@@ -0,0 +1,145 @@
+package day15
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/deosjr/adventofcode2018/grid"
+)
+
+const example = `#######
+#.G...#
+#...EG#
+#.#.#G#
+#..G#E#
+#.....#
+#######`
+
+func TestEvents(t *testing.T) {
+	var log Log
+	outcome, err := Battle(example, 3, &log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outcome != 27730 {
+		t.Errorf("got outcome %d want 27730", outcome)
+	}
+	// the first goblin steps towards the elf, the elf hits the
+	// goblin to its right, which hits back
+	want := []Event{
+		Moved{Round: 1, Unit: 0, From: grid.Coord{X: 2, Y: 1}, To: grid.Coord{X: 3, Y: 1}},
+		Attacked{Round: 1, Unit: 1, Target: 2, Damage: 3, HP: 197},
+		Attacked{Round: 1, Unit: 2, Target: 1, Damage: 3, HP: 197},
+	}
+	if !reflect.DeepEqual([]Event(log[:3]), want) {
+		t.Errorf("got %v want %v", log[:3], want)
+	}
+	rounds := 0
+	for _, e := range log {
+		if _, ok := e.(RoundEnded); ok {
+			rounds++
+		}
+	}
+	end := CombatEnded{Rounds: 47, HP: 590, Outcome: 27730}
+	if rounds != 47 || log[len(log)-1] != end {
+		t.Errorf("got %d rounds ending in %v want 47 ending in %v", rounds, log[len(log)-1], end)
+	}
+
+	var buf bytes.Buffer
+	sink := NewJSONLines(&buf)
+	if _, err := Battle(example, 3, sink); err != nil || sink.Err() != nil {
+		t.Fatal(err, sink.Err())
+	}
+	if !strings.HasPrefix(buf.String(), `{"type":"moved","event":{"round":1,"unit":0,"from":{"X":2,"Y":1},"to":{"X":3,"Y":1}}}`+"\n") {
+		t.Errorf("got %s", buf.String()[:100])
+	}
+	read, err := ReadJSONLines(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(read, []Event(log)) {
+		t.Error("events read back differ from the ones written")
+	}
+}
+
+func TestReplay(t *testing.T) {
+	var log Log
+	if _, err := Battle(example, 3, &log); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Replay(example, 3, log)
+	if err != nil || got != 27730 {
+		t.Errorf("got %d, %v want 27730", got, err)
+	}
+
+	var stronger15 Log
+	want, err := Battle(example, 15, &stronger15)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := Replay(example, 15, stronger15); err != nil || got != want {
+		t.Errorf("attack 15: got %d, %v want %d", got, err, want)
+	}
+
+	tampered := append(Log{}, log...)
+	tampered[1] = Attacked{Round: 1, Unit: 1, Target: 2, Damage: 3, HP: 196}
+	stronger := append(Log{}, log...)
+	stronger[1] = Attacked{Round: 1, Unit: 1, Target: 2, Damage: 4, HP: 196}
+	// event 160 is unit 2 killing unit 1, which dies in event 161
+	if log[160] != (Died{Round: 23, Unit: 1, At: grid.Coord{X: 4, Y: 2}}) {
+		t.Fatalf("got %v", log[160])
+	}
+	undead := append(append(Log{}, log[:160]...), log[161:]...)
+	deadAttacker := append(Log{}, log...)
+	deadAttacker[160] = Attacked{Round: 23, Unit: 1, Target: 2, Damage: 3, HP: 128}
+	deadTarget := append(Log{}, log...)
+	deadTarget[160] = Attacked{Round: 23, Unit: 4, Target: 1, Damage: 3, HP: -4}
+	moved := append(Log{}, log...)
+	moved[0] = Moved{Round: 1, Unit: 0, From: grid.Coord{X: 2, Y: 1}, To: grid.Coord{X: 1, Y: 2}}
+	for _, tt := range []struct {
+		name string
+		log  Log
+		err  string
+	}{
+		{"tampered", tampered, "event 2, round 1: unit 1 attacked unit 2 for 3 damage, leaving 196 hp: unit 2 has 197 hp left"},
+		{"stronger", stronger, "event 2, round 1: unit 1 attacked unit 2 for 4 damage, leaving 196 hp: unit 1 attacks for 3 damage"},
+		{"undead", undead, "event 161, round 23: unit 3 attacked unit 5 for 3 damage, leaving 131 hp: expected unit 1 to die"},
+		{"dead attacker", deadAttacker, "event 161, round 23: unit 1 attacked unit 2 for 3 damage, leaving 128 hp: unit 1 is dead"},
+		{"dead target", deadTarget, "event 161, round 23: unit 4 attacked unit 1 for 3 damage, leaving -4 hp: unit 1 is dead"},
+		{"diagonal", moved, "event 1, round 1: unit 0 moved from {2 1} to {1 2}: {1 2} is not next to {2 1}"},
+		{"truncated", log[:len(log)-1], "log ends before the combat does"},
+		{"missing events", log[5:], "event 3, round 2: unit 0 moved from {3 1} to {4 1}: unit 0 is at {2 1}"},
+	} {
+		_, err := Replay(example, 3, tt.log)
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("%s: got %v want %s", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestStopOnElfDeath(t *testing.T) {
+	game, err := parse(example)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log Log
+	game.events = &log
+	game.stop = isElf
+	part1(game)
+	if !game.stopped {
+		t.Fatal("battle did not stop")
+	}
+	// the first elf dies in round 23 of 48 and nothing happens after
+	died, ok := log[len(log)-1].(Died)
+	if !ok || died.Unit != 1 || died.Round != 23 {
+		t.Errorf("got last event %v", log[len(log)-1])
+	}
+	if _, ok, _ := elvesWin(example, 3); ok {
+		t.Error("elves win at attack power 3")
+	}
+	if outcome, ok, _ := elvesWin(example, 15); !ok || outcome != 4988 {
+		t.Errorf("got %d, %t want 4988 at attack power 15", outcome, ok)
+	}
+}
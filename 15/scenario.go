@@ -0,0 +1,234 @@
+package day15
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/deosjr/adventofcode2018/grid"
+	"github.com/deosjr/adventofcode2018/scan"
+)
+
+// faction is a side in a battle, standing for a letter on the map
+type faction struct {
+	symbol     rune
+	name       string
+	hp, attack int
+	// index is the order in which the faction was declared
+	index int
+	// enemies is the row of the allegiance matrix for this faction:
+	// the factions its units attack
+	enemies map[rune]bool
+}
+
+// override gives the unit starting at pos other stats than its faction
+type override struct {
+	pos        grid.Coord
+	hp, attack int
+	// where the override was read, to report units missing from the map
+	line int
+	text string
+}
+
+type scenario struct {
+	factions  map[rune]*faction
+	overrides []override
+}
+
+// defaultScenario is the puzzle: elves and goblins
+// with 200 hit points and 3 attack power, fighting each other
+func defaultScenario() scenario {
+	elves := &faction{symbol: 'E', name: "elves", hp: 200, attack: 3, index: 0}
+	goblins := &faction{symbol: 'G', name: "goblins", hp: 200, attack: 3, index: 1}
+	elves.enemies = map[rune]bool{'G': true}
+	goblins.enemies = map[rune]bool{'E': true}
+	return scenario{factions: map[rune]*faction{'E': elves, 'G': goblins}}
+}
+
+// mapFormat describes the characters allowed on the map
+func (sc scenario) mapFormat() string {
+	symbols := make([]rune, len(sc.factions))
+	for _, f := range sc.factions {
+		symbols[f.index] = f.symbol
+	}
+	return fmt.Sprintf("map of [#.%s]", string(symbols))
+}
+
+func isScenario(line string) bool {
+	keyword := strings.SplitN(line, " ", 2)[0]
+	return keyword == "faction" || keyword == "allegiance" || keyword == "unit"
+}
+
+// parseScenario reads the lines before the map up to an empty line:
+//
+//	faction E elves hp 200 attack 3
+//	faction G goblins
+//	faction D dwarves hp 300 attack 5
+//	allegiance
+//	  E G D
+//	E . x .
+//	G x . x
+//	D . x .
+//	unit 3 1 hp 100 attack 10
+//
+// Factions get 200 hp and 3 attack power unless given. Without any faction
+// lines the factions are the elves and goblins of the puzzle.
+// In the allegiance matrix an x means units of the faction of the row
+// attack units of the faction of the column. Without one every faction
+// attacks every other. A unit line changes the stats of the unit that
+// starts at x, y on the map
+func parseScenario(r *scan.Reader) (scenario, error) {
+	sc := scenario{factions: map[rune]*faction{}}
+	var order []rune
+	allegiance := false
+	for r.Scan() && r.Text() != "" {
+		fields := strings.Fields(r.Text())
+		switch fields[0] {
+		case "faction":
+			const format = "faction symbol name [hp n] [attack n]"
+			if allegiance {
+				return scenario{}, r.Errorf(format, "factions should come before the allegiance matrix")
+			}
+			if len(fields) < 3 || len([]rune(fields[1])) != 1 {
+				return scenario{}, r.Errorf(format, "expected a single letter and a name")
+			}
+			symbol := []rune(fields[1])[0]
+			if symbol == '#' || symbol == '.' || sc.factions[symbol] != nil {
+				return scenario{}, r.Errorf(format, "symbol %q is already taken", symbol)
+			}
+			f := &faction{symbol: symbol, name: fields[2], hp: 200, attack: 3, index: len(order)}
+			if err := stats(r, format, fields[3:], &f.hp, &f.attack); err != nil {
+				return scenario{}, err
+			}
+			sc.factions[symbol] = f
+			order = append(order, symbol)
+		case "allegiance":
+			if allegiance {
+				return scenario{}, r.Errorf("faction, allegiance or unit", "second allegiance matrix")
+			}
+			if len(order) == 0 {
+				sc.factions = defaultScenario().factions
+				order = []rune{'E', 'G'}
+				for _, f := range sc.factions {
+					f.enemies = nil
+				}
+			}
+			if err := parseAllegiance(r, sc.factions, order); err != nil {
+				return scenario{}, err
+			}
+			allegiance = true
+		case "unit":
+			const format = "unit x y [hp n] [attack n]"
+			o := override{line: r.Line(), text: r.Text()}
+			if len(fields) < 3 {
+				return scenario{}, r.Errorf(format, "expected a position")
+			}
+			var err error
+			if o.pos.X, err = strconv.Atoi(fields[1]); err != nil {
+				return scenario{}, r.Error(format, err)
+			}
+			if o.pos.Y, err = strconv.Atoi(fields[2]); err != nil {
+				return scenario{}, r.Error(format, err)
+			}
+			if err := stats(r, format, fields[3:], &o.hp, &o.attack); err != nil {
+				return scenario{}, err
+			}
+			sc.overrides = append(sc.overrides, o)
+		default:
+			return scenario{}, r.Errorf("faction, allegiance or unit", "unknown keyword %q", fields[0])
+		}
+	}
+	if len(order) == 0 {
+		sc.factions = defaultScenario().factions
+	}
+	if !allegiance {
+		for _, f := range sc.factions {
+			f.enemies = map[rune]bool{}
+			for _, g := range sc.factions {
+				if f != g {
+					f.enemies[g.symbol] = true
+				}
+			}
+		}
+	}
+	return sc, nil
+}
+
+// stats reads the optional hp and attack pairs at the end of a line
+func stats(r *scan.Reader, format string, fields []string, hp, attack *int) error {
+	if len(fields)%2 != 0 {
+		return r.Errorf(format, "expected pairs of a stat and a number")
+	}
+	for i := 0; i < len(fields); i += 2 {
+		n, err := strconv.Atoi(fields[i+1])
+		if err != nil {
+			return r.Error(format, err)
+		}
+		if n <= 0 {
+			return r.Errorf(format, "%s should be positive", fields[i])
+		}
+		switch fields[i] {
+		case "hp":
+			*hp = n
+		case "attack":
+			*attack = n
+		default:
+			return r.Errorf(format, "unknown stat %q", fields[i])
+		}
+	}
+	return nil
+}
+
+// parseAllegiance reads the header and a row per faction of the allegiance matrix
+func parseAllegiance(r *scan.Reader, factions map[rune]*faction, order []rune) error {
+	const format = "a row of x and . per faction"
+	if !r.Scan() {
+		return r.Errorf(format, "missing allegiance matrix")
+	}
+	columns := []rune{}
+	seen := map[rune]bool{}
+	for _, field := range strings.Fields(r.Text()) {
+		c := []rune(field)
+		if len(c) != 1 || factions[c[0]] == nil {
+			return r.Errorf("faction symbols", "unknown faction %q", field)
+		}
+		if seen[c[0]] {
+			return r.Errorf("faction symbols", "second column for faction %q", c[0])
+		}
+		seen[c[0]] = true
+		columns = append(columns, c[0])
+	}
+	for _, symbol := range order {
+		if !seen[symbol] {
+			return r.Errorf("faction symbols", "no column for faction %q", symbol)
+		}
+	}
+	for range order {
+		if !r.Scan() || r.Text() == "" {
+			return r.Errorf(format, "expected a row for each of %d factions", len(order))
+		}
+		fields := strings.Fields(r.Text())
+		row := []rune(fields[0])
+		if len(row) != 1 || factions[row[0]] == nil {
+			return r.Errorf(format, "unknown faction %q", fields[0])
+		}
+		f := factions[row[0]]
+		if f.enemies != nil {
+			return r.Errorf(format, "second row for faction %q", f.symbol)
+		}
+		if len(fields) != len(columns)+1 {
+			return r.Errorf(format, "expected %d columns", len(columns))
+		}
+		f.enemies = map[rune]bool{}
+		for i, field := range fields[1:] {
+			switch field {
+			case "x":
+				f.enemies[columns[i]] = true
+			case ".":
+			default:
+				return r.Errorf(format, "unexpected %q", field)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package day15
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScenario(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		scenario string
+		board    string
+		outcome  int
+		// left is the map after the battle
+		left string
+	}{
+		{
+			name:     "puzzle spelled out",
+			scenario: "faction E elves hp 200 attack 3\nfaction G goblins",
+			board:    example,
+			outcome:  27730,
+			left:     "#######\n#G....#\n#.G...#\n#.#.#G#\n#...#.#\n#....G#\n#######",
+		},
+		{
+			name:     "stronger elf",
+			scenario: "unit 4 2 attack 200",
+			board:    example,
+			outcome:  1146,
+			left:     "#######\n#.....#\n#....E#\n#.#.#.#\n#...#E#\n#.....#\n#######",
+		},
+		{
+			// the dwarf helps the elf, the goblin only hits the elf once
+			name:     "alliance",
+			scenario: "faction E elves\nfaction G goblins hp 9\nfaction D dwarves hp 300\nallegiance\n  E G D\nE . x .\nG x . x\nD . x .",
+			board:    "#####\n#EGD#\n#####",
+			outcome:  1 * 497,
+			left:     "#####\n#E.D#\n#####",
+		},
+		{
+			// brawlers attack each other, the last one standing wins
+			name:     "free for all",
+			scenario: "faction B brawlers hp 6\nallegiance\n  B\nB x",
+			board:    "#####\n#BBB#\n#####",
+			outcome:  2 * 3,
+			left:     "#####\n#..B#\n#####",
+		},
+	} {
+		outcome, err := Battle(tt.scenario+"\n\n"+tt.board, 0, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		game, err := parse(tt.scenario + "\n\n" + tt.board)
+		if err != nil {
+			t.Fatal(err)
+		}
+		part1(game)
+		rows := strings.Split(tt.board, "\n")
+		left := game.testPrint(len(rows[0]), len(rows), false)
+		if outcome != tt.outcome || left != tt.left {
+			t.Errorf("%s: got outcome %d\n%s\nwant %d\n%s", tt.name, outcome, left, tt.outcome, tt.left)
+		}
+	}
+}
+
+func TestScenarioErrors(t *testing.T) {
+	for _, tt := range []struct {
+		input string
+		err   string
+	}{
+		{
+			input: "faction D dwarves\n\n#EG#",
+			err:   `line 3: cannot parse "#EG#" as "map of [#.D]": unexpected character 'E' at column 2`,
+		},
+		{
+			input: "faction # walls\n\n#",
+			err:   `line 1: cannot parse "faction # walls" as "faction symbol name [hp n] [attack n]": symbol '#' is already taken`,
+		},
+		{
+			input: "faction E elves hp lots\n\n#E#",
+			err:   `line 1: cannot parse "faction E elves hp lots" as "faction symbol name [hp n] [attack n]": strconv.Atoi: parsing "lots": invalid syntax`,
+		},
+		{
+			input: "allegiance\n  E G\nE . x\n\n#EG#",
+			err:   `line 4: cannot parse "" as "a row of x and . per faction": expected a row for each of 2 factions`,
+		},
+		{
+			input: "allegiance\n  E E\nE . x\nG x .\n\n#EG#",
+			err:   `line 2: cannot parse "  E E" as "faction symbols": second column for faction 'E'`,
+		},
+		{
+			input: "faction E elves\nfaction G goblins\nfaction D dwarves\nallegiance\n  E G\nE . x\nG x .\n\n#EG#",
+			err:   `line 5: cannot parse "  E G" as "faction symbols": no column for faction 'D'`,
+		},
+		{
+			input: "unit 1 0 hp 3\n\n#.G#",
+			err:   `line 1: cannot parse "unit 1 0 hp 3" as "unit x y [hp n] [attack n]": no unit at 1,0 on the map`,
+		},
+		{
+			input: "units 1 0\n\n#.G#",
+			err:   "",
+		},
+	} {
+		_, err := parse(tt.input)
+		if tt.err == "" {
+			// not a scenario, so the first line is read as the map
+			if err == nil {
+				t.Errorf("%q: expected error", tt.input)
+			}
+			continue
+		}
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("got error %v want %s", err, tt.err)
+		}
+	}
+}
+
+func TestOwnAttack(t *testing.T) {
+	game, err := parse("unit 4 2 attack 200\n\n" + example)
+	if err != nil {
+		t.Fatal(err)
+	}
+	game.setElfAttack(10)
+	for _, u := range game.units {
+		want := 3
+		switch {
+		case u.Pos().X == 4 && u.Pos().Y == 2:
+			want = 200
+		case isElf(u):
+			want = 10
+		}
+		if u.Attack() != want {
+			t.Errorf("unit %d at %v: got attack %d want %d", u.ID(), u.Pos(), u.Attack(), want)
+		}
+	}
+}
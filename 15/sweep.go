@@ -0,0 +1,106 @@
+package day15
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Survivors counts the units of a faction left at the end of a battle
+type Survivors struct {
+	Faction rune
+	Name    string
+	Units   int
+}
+
+// Result sums up a battle fought with the elves at a given attack power
+type Result struct {
+	Power int
+	// Rounds is the number of full rounds, HP the hit points left over all units
+	Rounds, HP, Outcome int
+	// Survivors has an entry for every faction in the order they are declared
+	Survivors []Survivors
+	// ElfDeaths lists the elves that died, in the order they did
+	ElfDeaths []Died
+}
+
+// elfDeaths is a sink keeping the deaths of elves only
+type elfDeaths struct {
+	elves map[int]bool
+	died  []Died
+}
+
+func (d *elfDeaths) Emit(e Event) {
+	if died, ok := e.(Died); ok && d.elves[died.Unit] {
+		d.died = append(d.died, died)
+	}
+}
+
+// fight plays out the whole battle with the elves at the given attack power
+func fight(input string, power int) (Result, error) {
+	game, err := parse(input)
+	if err != nil {
+		return Result{}, err
+	}
+	game.setElfAttack(power)
+	deaths := &elfDeaths{elves: map[int]bool{}}
+	for _, u := range game.units {
+		deaths.elves[u.ID()] = isElf(u)
+	}
+	game.events = deaths
+	outcome := part1(game)
+
+	r := Result{Power: power, Rounds: game.rounds, HP: game.hp(), Outcome: outcome, ElfDeaths: deaths.died}
+	for _, f := range game.factions {
+		s := Survivors{Faction: f.symbol, Name: f.name}
+		for _, u := range game.units {
+			if u.Faction() == f.symbol {
+				s.Units++
+			}
+		}
+		r.Survivors = append(r.Survivors, s)
+	}
+	return r, nil
+}
+
+// Sweep fights the battle once for every elf attack power from one to
+// the other, on the given number of workers at the same time, and returns
+// the results in order of attack power
+func Sweep(input string, from, to, workers int) ([]Result, error) {
+	if from < 1 {
+		return nil, fmt.Errorf("attack power %d is below 1", from)
+	}
+	if from > to {
+		return nil, fmt.Errorf("empty range of attack powers %d to %d", from, to)
+	}
+	if workers < 1 {
+		return nil, fmt.Errorf("%d workers cannot fight any battle", workers)
+	}
+	// report malformed input once instead of for every power
+	if _, err := parse(input); err != nil {
+		return nil, err
+	}
+	results := make([]Result, to-from+1)
+	errs := make([]error, to-from+1)
+	powers := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range powers {
+				results[p-from], errs[p-from] = fight(input, p)
+			}
+		}()
+	}
+	for p := from; p <= to; p++ {
+		powers <- p
+	}
+	close(powers)
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
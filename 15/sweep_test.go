@@ -0,0 +1,56 @@
+package day15
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/deosjr/adventofcode2018/grid"
+)
+
+func TestSweep(t *testing.T) {
+	results, err := Sweep(example, 3, 15, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 13 {
+		t.Fatalf("got %d results want 13", len(results))
+	}
+	want := Result{
+		Power: 3, Rounds: 47, HP: 590, Outcome: 27730,
+		Survivors: []Survivors{{'E', "elves", 0}, {'G', "goblins", 4}},
+		ElfDeaths: []Died{
+			{Round: 23, Unit: 1, At: grid.Coord{X: 4, Y: 2}},
+			{Round: 47, Unit: 5, At: grid.Coord{X: 5, Y: 4}},
+		},
+	}
+	if !reflect.DeepEqual(results[0], want) {
+		t.Errorf("got %+v want %+v", results[0], want)
+	}
+	// 15 is the lowest power at which no elf dies, see TestPart2
+	last := results[12]
+	if last.Power != 15 || last.Outcome != 4988 || len(last.ElfDeaths) != 0 {
+		t.Errorf("got %+v", last)
+	}
+	for _, r := range results[:12] {
+		if len(r.ElfDeaths) == 0 {
+			t.Errorf("no elf dies at power %d", r.Power)
+		}
+	}
+
+	sequential, err := Sweep(example, 3, 15, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(results, sequential) {
+		t.Error("results depend on the number of workers")
+	}
+	if _, err := Sweep(example, 5, 4, 1); err == nil {
+		t.Error("expected error for an empty range")
+	}
+	if _, err := Sweep(example, 0, 4, 1); err == nil {
+		t.Error("expected error for attack power 0")
+	}
+	if _, err := Sweep(example, 3, 4, 0); err == nil {
+		t.Error("expected error for no workers")
+	}
+}
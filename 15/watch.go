@@ -0,0 +1,115 @@
+package day15
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/deosjr/adventofcode2018/grid"
+)
+
+// ErrStopped is returned by Watch if the watcher stopped the battle
+var ErrStopped = errors.New("battle stopped")
+
+// ANSI escape codes used to draw a frame
+const (
+	ansiReset   = "\x1b[0m"
+	ansiReverse = "\x1b[7m"
+	ansiTarget  = "\x1b[1;41m"
+	ansiElf     = "\x1b[32m"
+	ansiGoblin  = "\x1b[31m"
+)
+
+// Turn is the state of a battle right after a unit took its turn.
+// The battle goes on once watch returns, so draw it before that
+type Turn struct {
+	// Round is the round being played, starting at 1
+	Round int
+	// Mover is the unit whose turn it was, Target the unit it attacked or nil
+	Mover, Target Unit
+	game          *gameState
+}
+
+// Frame draws the map with the HP of the units on each row behind it,
+// each faction in its own colour, elves in green and goblins in red.
+// The mover is shown in reverse video and its target on a red background
+func (t Turn) Frame() string {
+	return t.game.frame(t.Mover, t.Target, true)
+}
+
+// String draws the map with the HP of the units but without colours
+func (t Turn) String() string {
+	return t.game.frame(t.Mover, t.Target, false)
+}
+
+// Watch plays out the battle with every elf at attack power elfAttack,
+// unless it is 0 or the scenario gives that elf an attack of its own.
+// It calls watch after every turn and sends every event to sink, if set.
+// If watch returns false the battle stops and Watch returns ErrStopped,
+// otherwise it returns the outcome
+func Watch(input string, elfAttack int, watch func(Turn) bool, sink Sink) (int, error) {
+	game, err := parse(input)
+	if err != nil {
+		return 0, err
+	}
+	if elfAttack > 0 {
+		game.setElfAttack(elfAttack)
+	}
+	game.watch = func(mover, target Unit) bool {
+		return watch(Turn{Round: game.rounds + 1, Mover: mover, Target: target, game: game})
+	}
+	game.events = sink
+	outcome := part1(game)
+	if game.stopped {
+		return 0, ErrStopped
+	}
+	return outcome, nil
+}
+
+func (game *gameState) frame(mover, target Unit, styled bool) string {
+	var sb strings.Builder
+	for y := 0; y < game.tiles.height; y++ {
+		units := []string{}
+		for x := 0; x < game.tiles.width; x++ {
+			t := game.tiles.get(grid.Coord{X: x, Y: y})
+			switch {
+			case t.unit != nil:
+				r, hp := unitRune(t.unit), fmt.Sprintf("%c(%d)", unitRune(t.unit), t.unit.HP())
+				if !styled {
+					sb.WriteRune(r)
+					units = append(units, hp)
+					continue
+				}
+				style := unitStyle(t.unit)
+				switch t.unit {
+				case mover:
+					style += ansiReverse
+				case target:
+					style = ansiTarget
+				}
+				fmt.Fprintf(&sb, "%s%c%s", style, r, ansiReset)
+				units = append(units, style+hp+ansiReset)
+			case t.isWall:
+				sb.WriteString("#")
+			default:
+				sb.WriteString(".")
+			}
+		}
+		if len(units) > 0 {
+			sb.WriteString("   " + strings.Join(units, ", "))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func unitRune(u Unit) rune {
+	return u.Faction()
+}
+
+// factionStyles colour the factions in the order they are declared
+var factionStyles = []string{ansiElf, ansiGoblin, "\x1b[33m", "\x1b[34m", "\x1b[35m", "\x1b[36m"}
+
+func unitStyle(u Unit) string {
+	return factionStyles[u.(*unit).faction.index%len(factionStyles)]
+}
@@ -0,0 +1,64 @@
+package day15
+
+import (
+	"testing"
+
+	"github.com/deosjr/adventofcode2018/grid"
+)
+
+func TestWatch(t *testing.T) {
+	var first, last Turn
+	var firstPos grid.Coord
+	turns := 0
+	outcome, err := Watch(example, 3, func(turn Turn) bool {
+		if turns == 0 {
+			first, firstPos = turn, turn.Mover.Pos()
+		}
+		last = turn
+		turns++
+		return true
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outcome != 27730 {
+		t.Errorf("got outcome %d want 27730", outcome)
+	}
+	// round 48 ends before anyone takes a turn
+	if last.Round != 47 {
+		t.Errorf("got last round %d want 47", last.Round)
+	}
+	// the first goblin moves right but cannot attack yet
+	if first.Round != 1 || firstPos != (grid.Coord{X: 3, Y: 1}) || first.Target != nil {
+		t.Errorf("got first turn in round %d to %v attacking %v", first.Round, firstPos, first.Target)
+	}
+
+	stopped := 0
+	_, err = Watch(example, 3, func(turn Turn) bool {
+		stopped++
+		return stopped < 5
+	}, nil)
+	if err != ErrStopped || stopped != 5 {
+		t.Errorf("got %v after %d turns", err, stopped)
+	}
+}
+
+func TestFrame(t *testing.T) {
+	game, err := parse("#####\n#GE.#\n#####")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, e := game.units[0], game.units[1]
+	e.Damage(3)
+	got := Turn{Mover: g, Target: e, game: game}.String()
+	want := "#####\n#GE.#   G(200), E(197)\n#####\n"
+	if got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+	got = Turn{Mover: g, Target: e, game: game}.Frame()
+	want = "#####\n#" + ansiGoblin + ansiReverse + "G" + ansiReset + ansiTarget + "E" + ansiReset + ".#   " +
+		ansiGoblin + ansiReverse + "G(200)" + ansiReset + ", " + ansiTarget + "E(197)" + ansiReset + "\n#####\n"
+	if got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+}
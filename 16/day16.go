@@ -1,228 +1,357 @@
-package main
+package day16
 
 import (
 	"fmt"
-	"io/ioutil"
+	"math/bits"
+	"sort"
 	"strings"
+
+	"github.com/deosjr/adventofcode2018/elfcode"
+	"github.com/deosjr/adventofcode2018/scan"
 )
 
 type register [4]int
 
-type opcode func(register, int, int, int) register
-
-func addr(r register, a, b, c int) register {
-	r[c] = r[a] + r[b]
-	return r
+type instruction struct {
+	opcode  int
+	a, b, c int
+	// line in the input, 0 for the instructions of samples
+	line int
 }
 
-func addi(r register, a, b, c int) register {
-	r[c] = r[a] + b
-	return r
+type sample struct {
+	// line of the Before: in the input
+	line        int
+	before      register
+	instruction instruction
+	after       register
 }
 
-func mulr(r register, a, b, c int) register {
-	r[c] = r[a] * r[b]
-	return r
+// behavesLike returns true if op turns the before registers into the after registers
+func (s sample) behavesLike(op elfcode.Op) (ok bool) {
+	// using a register that does not exist rules the op out
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	r := s.before
+	elfcode.Instruction{Op: op, A: s.instruction.a, B: s.instruction.b, C: s.instruction.c}.Execute(r[:])
+	return r == s.after
 }
 
-func muli(r register, a, b, c int) register {
-	r[c] = r[a] * b
-	return r
+func parseSamples(r *scan.Reader) ([]sample, error) {
+	samples := []sample{}
+	for r.Scan() {
+		if !strings.HasPrefix(r.Text(), "Before:") {
+			break
+		}
+		s := sample{line: r.Line()}
+		b, i, a := &s.before, &s.instruction, &s.after
+		if err := r.Scanf("Before: [%d, %d, %d, %d]", &b[0], &b[1], &b[2], &b[3]); err != nil {
+			return nil, err
+		}
+		if err := r.Next("%d %d %d %d", &i.opcode, &i.a, &i.b, &i.c); err != nil {
+			return nil, err
+		}
+		if err := r.Next("After:  [%d, %d, %d, %d]", &a[0], &a[1], &a[2], &a[3]); err != nil {
+			return nil, err
+		}
+		samples = append(samples, s)
+		if r.Scan() && r.Text() != "" {
+			return nil, r.Errorf("empty line", "expected empty line after sample")
+		}
+	}
+	return samples, nil
 }
 
-func banr(r register, a, b, c int) register {
-	r[c] = r[a] & r[b]
-	return r
+func part1(samples []sample) int {
+	amount := 0
+	for _, s := range samples {
+		behavesLike := 0
+		for _, op := range elfcode.Ops {
+			if s.behavesLike(op) {
+				behavesLike++
+			}
+			if behavesLike >= 3 {
+				amount++
+				break
+			}
+		}
+	}
+	return amount
 }
 
-func bani(r register, a, b, c int) register {
-	r[c] = r[a] & b
-	return r
+// parseProgram reads the rest of the input, skipping empty lines
+// that separate it from the samples
+func parseProgram(r *scan.Reader) ([]instruction, error) {
+	program := []instruction{}
+	for r.Text() == "" {
+		if !r.Scan() {
+			return program, nil
+		}
+	}
+	for {
+		var opcode, a, b, c int
+		if err := r.Scanf("%d %d %d %d", &opcode, &a, &b, &c); err != nil {
+			return nil, err
+		}
+		// c is always written to, a and b are checked once the op is known
+		if c < 0 || c >= len(register{}) {
+			return nil, r.Errorf("%d %d %d %d", "register c=%d out of range, there are %d registers", c, len(register{}))
+		}
+		program = append(program, instruction{opcode, a, b, c, r.Line()})
+		if !r.Scan() {
+			return program, nil
+		}
+	}
 }
 
-func borr(r register, a, b, c int) register {
-	r[c] = r[a] | r[b]
-	return r
-}
+// candidates is a set of indices into a list of ops
+type candidates uint64
 
-func bori(r register, a, b, c int) register {
-	r[c] = r[a] | b
-	return r
+func (c candidates) names(ops []elfcode.Op) []string {
+	names := []string{}
+	for i, op := range ops {
+		if c&(1<<i) != 0 {
+			names = append(names, op.Name)
+		}
+	}
+	return names
 }
 
-func setr(r register, a, b, c int) register {
-	r[c] = r[a]
-	return r
+// solveError explains why the samples do not determine every opcode
+type solveError struct {
+	contradictions []string
+	// unresolved maps an opcode to the ops it could still be
+	unresolved map[int][]string
 }
 
-func seti(r register, a, b, c int) register {
-	r[c] = a
-	return r
+func (e *solveError) Error() string {
+	msgs := append([]string{}, e.contradictions...)
+	opcodes := []int{}
+	for opcode := range e.unresolved {
+		opcodes = append(opcodes, opcode)
+	}
+	sort.Ints(opcodes)
+	for _, opcode := range opcodes {
+		msgs = append(msgs, fmt.Sprintf("opcode %d could be any of %s", opcode, strings.Join(e.unresolved[opcode], ", ")))
+	}
+	return "cannot determine opcodes: " + strings.Join(msgs, "; ")
 }
 
-func gtir(r register, a, b, c int) register {
-	if a > r[b] {
-		r[c] = 1
-		return r
-	}
-	r[c] = 0
-	return r
+// observation is what a sample tells about its opcode: the ops it behaves like
+type observation struct {
+	sample  sample
+	opcode  int
+	matches candidates
 }
 
-func gtri(r register, a, b, c int) register {
-	if r[a] > b {
-		r[c] = 1
-		return r
+func observe(samples []sample, ops []elfcode.Op) ([]observation, error) {
+	if len(ops) > 64 {
+		return nil, fmt.Errorf("cannot solve for %d ops, at most 64", len(ops))
 	}
-	r[c] = 0
-	return r
+	obs := make([]observation, len(samples))
+	for i, s := range samples {
+		obs[i] = observation{sample: s, opcode: s.instruction.opcode}
+		for j, op := range ops {
+			if s.behavesLike(op) {
+				obs[i].matches |= 1 << j
+			}
+		}
+	}
+	return obs, nil
 }
 
-func gtrr(r register, a, b, c int) register {
-	if r[a] > r[b] {
-		r[c] = 1
-		return r
+// determineOpcodes finds the op for every opcode in the samples
+func determineOpcodes(samples []sample, ops []elfcode.Op) (map[int]elfcode.Op, error) {
+	obs, err := observe(samples, ops)
+	if err != nil {
+		return nil, err
 	}
-	r[c] = 0
-	return r
+	return solve(obs, ops)
 }
 
-func eqir(r register, a, b, c int) register {
-	if a == r[b] {
-		r[c] = 1
-		return r
+// narrow intersects the ops each opcode could be over all observations
+func narrow(obs []observation, ops []elfcode.Op) (map[int]candidates, *solveError) {
+	all := candidates(1<<len(ops) - 1)
+	cands := map[int]candidates{}
+	serr := &solveError{unresolved: map[int][]string{}}
+	for _, o := range obs {
+		if _, ok := cands[o.opcode]; !ok {
+			cands[o.opcode] = all
+		}
+		if o.matches == 0 {
+			serr.contradictions = append(serr.contradictions, fmt.Sprintf("sample on line %d matches no op", o.sample.line))
+			continue
+		}
+		if cands[o.opcode]&o.matches == 0 {
+			serr.contradictions = append(serr.contradictions, fmt.Sprintf("sample on line %d behaves like %s, ruled out for opcode %d by earlier samples",
+				o.sample.line, strings.Join(o.matches.names(ops), ", "), o.opcode))
+			continue
+		}
+		cands[o.opcode] &= o.matches
 	}
-	r[c] = 0
-	return r
+	return cands, serr
 }
 
-func eqri(r register, a, b, c int) register {
-	if r[a] == b {
-		r[c] = 1
-		return r
+// solve narrows down the ops each opcode could be using all observations,
+// then keeps removing ops that are known to belong to another
+// opcode until every opcode has a single op left
+func solve(obs []observation, ops []elfcode.Op) (map[int]elfcode.Op, error) {
+	cands, serr := narrow(obs, ops)
+	for changed := true; changed; {
+		changed = false
+		for opcode, c := range cands {
+			if bits.OnesCount64(uint64(c)) != 1 {
+				continue
+			}
+			for other, oc := range cands {
+				if other != opcode && oc&c != 0 {
+					cands[other] = oc &^ c
+					changed = true
+				}
+			}
+		}
+		// if every op is used, an op that only one opcode
+		// can still be belongs to that opcode
+		if len(cands) != len(ops) {
+			continue
+		}
+		for i := range ops {
+			var only int
+			n := 0
+			for opcode, c := range cands {
+				if c&(1<<i) != 0 {
+					only = opcode
+					n++
+				}
+			}
+			if n == 1 && cands[only] != 1<<i {
+				cands[only] = 1 << i
+				changed = true
+			}
+		}
 	}
-	r[c] = 0
-	return r
-}
 
-func eqrr(r register, a, b, c int) register {
-	if r[a] == r[b] {
-		r[c] = 1
-		return r
+	mapping := map[int]elfcode.Op{}
+	empty := []int{}
+	for opcode, c := range cands {
+		switch bits.OnesCount64(uint64(c)) {
+		case 0:
+			empty = append(empty, opcode)
+		case 1:
+			mapping[opcode] = ops[bits.TrailingZeros64(uint64(c))]
+		default:
+			serr.unresolved[opcode] = c.names(ops)
+		}
 	}
-	r[c] = 0
-	return r
+	sort.Ints(empty)
+	for _, opcode := range empty {
+		serr.contradictions = append(serr.contradictions, fmt.Sprintf("opcode %d has no ops left", opcode))
+	}
+	if len(serr.contradictions) > 0 || len(serr.unresolved) > 0 {
+		return nil, serr
+	}
+	return mapping, nil
 }
 
-type instruction struct {
-	opcode  int
-	a, b, c int
+func part2(samples []sample, program []instruction) (int, error) {
+	opcodes, err := determineOpcodes(samples, elfcode.Ops)
+	if err != nil {
+		return 0, err
+	}
+	p, err := decode(program, opcodes)
+	if err != nil {
+		return 0, err
+	}
+	vm := elfcode.New(p, len(register{}))
+	vm.Run()
+	return vm.Registers[0], nil
 }
 
-type sample struct {
-	before      register
-	instruction instruction
-	after       register
+// decode turns the numeric program into ops using the opcodes found
+func decode(program []instruction, opcodes map[int]elfcode.Op) (elfcode.Program, error) {
+	p := elfcode.Program{IPRegister: -1}
+	for i, ins := range program {
+		op, ok := opcodes[ins.opcode]
+		if !ok {
+			return elfcode.Program{}, fmt.Errorf("instruction %d: opcode %d does not occur in any sample", i, ins.opcode)
+		}
+		decoded := elfcode.Instruction{Op: op, A: ins.a, B: ins.b, C: ins.c}
+		if err := decoded.Check(len(register{})); err != nil {
+			return elfcode.Program{}, &scan.ParseError{
+				Line:     ins.line,
+				Text:     fmt.Sprintf("%d %d %d %d", ins.opcode, ins.a, ins.b, ins.c),
+				Expected: "%d %d %d %d",
+				Err:      fmt.Errorf("%s: %w", op, err),
+			}
+		}
+		p.Instructions = append(p.Instructions, decoded)
+	}
+	return p, nil
 }
 
-func parseSamples(input string) []sample {
-	samples := []sample{}
-	for _, lines := range strings.Split(input, "\n\n") {
-		var b0, b1, b2, b3 int
-		var i0, i1, i2, i3 int
-		var a0, a1, a2, a3 int
-		fmt.Sscanf(lines, `Before: [%d, %d, %d, %d]
-%d %d %d %d
-After:  [%d, %d, %d, %d]`, &b0, &b1, &b2, &b3, &i0, &i1, &i2, &i3, &a0, &a1, &a2, &a3)
-		s := sample{
-			before:      [4]int{b0, b1, b2, b3},
-			instruction: instruction{i0, i1, i2, i3},
-			after:       [4]int{a0, a1, a2, a3},
-		}
-		samples = append(samples, s)
+// parse reads the samples, separated by empty lines,
+// followed by the test program after some more empty lines
+func parse(input string) ([]sample, []instruction, error) {
+	r := scan.NewReader(input)
+	samples, err := parseSamples(r)
+	if err != nil {
+		return nil, nil, err
 	}
-	return samples
+	program, err := parseProgram(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return samples, program, nil
 }
 
-func part1(samples []sample) int {
-	opcodes := []opcode{addr, addi, mulr, muli, banr, bani, borr, bori, setr, seti, gtir, gtri, gtrr, eqir, eqri, eqrr}
-	amount := 0
-	for _, s := range samples {
-		behavesLike := 0
-		for _, f := range opcodes {
-			after := f(s.before, s.instruction.a, s.instruction.b, s.instruction.c)
-			if after == s.after {
-				behavesLike++
-			}
-			if behavesLike >= 3 {
-				amount++
-				break
-			}
-		}
+func Part1(input string) (interface{}, error) {
+	samples, _, err := parse(input)
+	if err != nil {
+		return nil, err
 	}
-	return amount
+	return part1(samples), nil
 }
 
-func parseProgram(input string) []instruction {
-	split := strings.Split(input, "\n")
-	program := make([]instruction, len(split))
-	for i, s := range split {
-		var opcode, a, b, c int
-		fmt.Sscanf(s, "%d %d %d %d", &opcode, &a, &b, &c)
-		program[i] = instruction{opcode, a, b, c}
+func Part2(input string) (interface{}, error) {
+	samples, program, err := parse(input)
+	if err != nil {
+		return nil, err
 	}
-	return program
+	return part2(samples, program)
 }
 
-func determineOpcodes(samples []sample) map[int]opcode {
-	opcodes := []opcode{addr, addi, mulr, muli, banr, bani, borr, bori, setr, seti, gtir, gtri, gtrr, eqir, eqri, eqrr}
-	found := map[int]struct{}{}
-	mapping := map[int]opcode{}
-	for len(mapping) < len(opcodes) {
-	Samples:
-		for _, s := range samples {
-			if _, ok := mapping[s.instruction.opcode]; ok {
-				continue
-			}
-			behavesLike := -1
-			for i, f := range opcodes {
-				if _, ok := found[i]; ok {
-					continue
-				}
-				after := f(s.before, s.instruction.a, s.instruction.b, s.instruction.c)
-				if after == s.after {
-					if behavesLike != -1 {
-						continue Samples
-					}
-					behavesLike = i
-				}
-			}
-			mapping[s.instruction.opcode] = opcodes[behavesLike]
-			found[behavesLike] = struct{}{}
-		}
+// Report matches the samples in the input against a table of ops,
+// see report
+func Report(input string, ops []elfcode.Op) (string, error) {
+	samples, _, err := parse(input)
+	if err != nil {
+		return "", err
 	}
-	return mapping
+	return report(samples, ops)
 }
 
-func part2(samples []sample, program []instruction) int {
-	opcodes := determineOpcodes(samples)
-	r := [4]int{0, 0, 0, 0}
-	for _, ins := range program {
-		f := opcodes[ins.opcode]
-		r = f(r, ins.a, ins.b, ins.c)
+// Opcodes finds the op for every opcode in the samples in the input
+func Opcodes(input string, ops []elfcode.Op) (map[int]elfcode.Op, error) {
+	samples, _, err := parse(input)
+	if err != nil {
+		return nil, err
 	}
-	return r[0]
+	return determineOpcodes(samples, ops)
 }
 
-func main() {
-	input, err := ioutil.ReadFile("day16.input")
+// Program decodes the test program in the input using the opcodes
+// found from the samples
+func Program(input string, ops []elfcode.Op) (elfcode.Program, error) {
+	samples, program, err := parse(input)
 	if err != nil {
-		panic(err)
-	}
-	twoParts := strings.Split(string(input), "\n\n\n\n")
-	part1input, part2input := twoParts[0], twoParts[1]
-	samples := parseSamples(part1input)
-	fmt.Printf("Part 1: %d\n", part1(samples))
-	program := parseProgram(part2input)
-	fmt.Printf("Part 2: %d\n", part2(samples, program))
+		return elfcode.Program{}, err
+	}
+	opcodes, err := determineOpcodes(samples, ops)
+	if err != nil {
+		return elfcode.Program{}, err
+	}
+	return decode(program, opcodes)
 }
@@ -0,0 +1,171 @@
+package day16
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/deosjr/adventofcode2018/elfcode"
+	"github.com/deosjr/adventofcode2018/scan"
+)
+
+func TestPart1(t *testing.T) {
+	samples, err := parseSamples(scan.NewReader(`Before: [3, 2, 1, 1]
+9 2 1 2
+After:  [3, 2, 2, 1]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := part1(samples)
+	if got != 1 {
+		t.Errorf("got %d want %d", got, 1)
+	}
+}
+
+func TestDetermineOpcodes(t *testing.T) {
+	ops := []elfcode.Op{elfcode.Addr, elfcode.Mulr, elfcode.Seti}
+	// opcode 0 behaves like mulr and seti, 1 like addr and 2 like seti
+	samples := `Before: [3, 2, 1, 1]
+0 2 1 2
+After:  [3, 2, 2, 1]
+
+Before: [0, 2, 1, 0]
+1 2 1 3
+After:  [0, 2, 1, 3]
+
+Before: [0, 0, 0, 0]
+2 5 0 1
+After:  [0, 5, 0, 0]`
+	for _, tt := range []struct {
+		name    string
+		samples string
+		want    map[int]string
+		err     string
+	}{
+		{
+			name:    "propagated",
+			samples: samples,
+			want:    map[int]string{0: "mulr", 1: "addr", 2: "seti"},
+		},
+		{
+			name:    "ambiguous",
+			samples: samples[:strings.LastIndex(samples, "\n\n")],
+			err:     "cannot determine opcodes: opcode 0 could be any of mulr, seti",
+		},
+		{
+			name:    "matches nothing",
+			samples: "Before: [0, 0, 0, 0]\n0 0 0 0\nAfter:  [9, 9, 9, 9]\n\n" + samples,
+			err:     "cannot determine opcodes: sample on line 1 matches no op",
+		},
+		{
+			name:    "contradiction",
+			samples: samples + "\n\nBefore: [0, 0, 0, 0]\n1 5 0 1\nAfter:  [0, 5, 0, 0]",
+			err:     "cannot determine opcodes: sample on line 13 behaves like seti, ruled out for opcode 1 by earlier samples",
+		},
+	} {
+		s, err := parseSamples(scan.NewReader(tt.samples))
+		if err != nil {
+			t.Fatal(err)
+		}
+		mapping, err := determineOpcodes(s, ops)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("%s: got error %v want %s", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		got := map[int]string{}
+		for opcode, op := range mapping {
+			got[opcode] = op.Name
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReport(t *testing.T) {
+	ops := []elfcode.Op{elfcode.Addr, elfcode.Mulr, elfcode.Seti, elfcode.Divi, elfcode.Modr}
+	// the second sample of opcode 2 tells nothing new
+	samples, err := parseSamples(scan.NewReader(`Before: [3, 2, 1, 1]
+0 2 1 2
+After:  [3, 2, 2, 1]
+
+Before: [7, 2, 0, 0]
+1 0 1 3
+After:  [7, 2, 0, 1]
+
+Before: [0, 0, 0, 0]
+2 5 0 1
+After:  [0, 5, 0, 0]
+
+Before: [0, 0, 0, 0]
+2 5 0 1
+After:  [0, 5, 0, 0]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := report(samples, ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `opcode  0: mulr  1 samples behave like mulr, seti
+opcode  1: modr  1 samples behave like modr
+opcode  2: seti  2 samples behave like seti
+1 of 4 samples behave like more than one op
+3 samples determine the mapping:
+	line 1: 0 2 1 2 behaves like mulr, seti
+	line 5: 1 0 1 3 behaves like modr
+	line 13: 2 5 0 1 behaves like seti
+`
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestGeneratedSamples(t *testing.T) {
+	for _, ops := range [][]elfcode.Op{elfcode.Ops, elfcode.Extended} {
+		rng := rand.New(rand.NewSource(16))
+		want := elfcode.RandomOpcodes(rng, ops)
+		input := elfcode.Samples(rng, want, 1000)
+		got, err := Opcodes(input, ops)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// ops hold funcs, so compare them by name
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("got %v want %v", got, want)
+		}
+	}
+}
+
+func TestProgramRegisters(t *testing.T) {
+	rng := rand.New(rand.NewSource(16))
+	opcodes := elfcode.RandomOpcodes(rng, elfcode.Ops)
+	samples := elfcode.Samples(rng, opcodes, 1000)
+	addr := 0
+	for n, op := range opcodes {
+		if op.Name == "addr" {
+			addr = n
+		}
+	}
+	for i, tt := range []struct {
+		program string
+		err     string
+	}{
+		{fmt.Sprintf("%d 0 0 4", addr), "register c=4 out of range, there are 4 registers"},
+		{fmt.Sprintf("%d 9 0 1", addr), "addr: register a=9 out of range, there are 4 registers"},
+	} {
+		input := samples + "\n\n\n" + tt.program
+		line := strings.Count(input, "\n") + 1
+		want := fmt.Sprintf("line %d: cannot parse %q as %q: %s", line, tt.program, "%d %d %d %d", tt.err)
+		if _, err := Part2(input); err == nil || err.Error() != want {
+			t.Errorf("%d): got %v want %s", i, err, want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package day16
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+	"strings"
+
+	"github.com/deosjr/adventofcode2018/elfcode"
+)
+
+// report describes what the samples tell about the opcodes when matched
+// against a table of ops: per opcode the ops all of its samples behave like
+// and the op it resolves to, how many samples behave like more than one op
+// and a set of samples that determines the mapping on its own
+func report(samples []sample, ops []elfcode.Op) (string, error) {
+	obs, err := observe(samples, ops)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	mapping, solveErr := solve(obs, ops)
+
+	cands, _ := narrow(obs, ops)
+	count := map[int]int{}
+	for _, o := range obs {
+		count[o.opcode]++
+	}
+	opcodes := []int{}
+	for opcode := range cands {
+		opcodes = append(opcodes, opcode)
+	}
+	sort.Ints(opcodes)
+	for _, opcode := range opcodes {
+		resolved := "?"
+		if op, ok := mapping[opcode]; ok {
+			resolved = op.Name
+		}
+		fmt.Fprintf(&sb, "opcode %2d: %-4s  %d samples behave like %s\n",
+			opcode, resolved, count[opcode], strings.Join(cands[opcode].names(ops), ", "))
+	}
+
+	ambiguous := 0
+	for _, o := range obs {
+		if bits.OnesCount64(uint64(o.matches)) > 1 {
+			ambiguous++
+		}
+	}
+	fmt.Fprintf(&sb, "%d of %d samples behave like more than one op\n", ambiguous, len(obs))
+
+	if solveErr != nil {
+		fmt.Fprintln(&sb, solveErr)
+		return sb.String(), nil
+	}
+	minimal := determining(obs, ops)
+	fmt.Fprintf(&sb, "%d samples determine the mapping:\n", len(minimal))
+	for _, o := range minimal {
+		i := o.sample.instruction
+		fmt.Fprintf(&sb, "\tline %d: %d %d %d %d behaves like %s\n",
+			o.sample.line, i.opcode, i.a, i.b, i.c, strings.Join(o.matches.names(ops), ", "))
+	}
+	return sb.String(), nil
+}
+
+// determining returns a subset of observations that still resolves every
+// opcode, such that leaving out any one of them no longer does.
+// It assumes the observations themselves resolve every opcode
+func determining(obs []observation, ops []elfcode.Op) []observation {
+	all, _ := solve(obs, ops)
+	keep := append([]observation{}, obs...)
+	for i := 0; i < len(keep); {
+		without := append(append([]observation{}, keep[:i]...), keep[i+1:]...)
+		// leaving out every sample of an opcode does not resolve it
+		if m, err := solve(without, ops); err == nil && len(m) == len(all) {
+			keep = without
+			continue
+		}
+		i++
+	}
+	return keep
+}
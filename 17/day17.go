@@ -1,15 +1,11 @@
-package main
+package day17
 
 import (
-	"fmt"
-	"io/ioutil"
-	"math"
 	"strings"
-)
 
-type coord struct {
-	x, y int
-}
+	"github.com/deosjr/adventofcode2018/grid"
+	"github.com/deosjr/adventofcode2018/scan"
+)
 
 type square uint8
 
@@ -20,69 +16,46 @@ const (
 	waterStanding
 )
 
+// bounds only covers the clay; water is allowed to flow outside of it on the x axis
 type slice struct {
-	squares    map[coord]square
-	xMin, xMax int
-	yMin, yMax int
+	squares *grid.Sparse[square]
+	bounds  grid.Box
 }
 
-func parse(input string) slice {
-	squares := map[coord]square{}
-	txMin, txMax := math.MaxInt64, math.MinInt64
-	tyMin, tyMax := math.MaxInt64, math.MinInt64
-	for _, s := range strings.Split(input, "\n") {
-		if s[0] == 'x' {
+func parse(input string) (slice, error) {
+	squares := grid.NewSparse[square]()
+	r := scan.NewReader(input)
+	for r.Scan() {
+		if strings.HasPrefix(r.Text(), "x") {
 			var x, yMin, yMax int
-			fmt.Sscanf(s, "x=%d, y=%d..%d", &x, &yMin, &yMax)
-			for y := yMin; y <= yMax; y++ {
-				squares[coord{x, y}] = clay
-			}
-			if x < txMin {
-				txMin = x
-			}
-			if x > txMax {
-				txMax = x
+			if err := r.Scanf("x=%d, y=%d..%d", &x, &yMin, &yMax); err != nil {
+				return slice{}, err
 			}
-			if yMin < tyMin {
-				tyMin = yMin
-			}
-			if yMax > tyMax {
-				tyMax = yMax
+			for y := yMin; y <= yMax; y++ {
+				squares.Set(grid.Coord{X: x, Y: y}, clay)
 			}
 			continue
 		}
 		var y, xMin, xMax int
-		fmt.Sscanf(s, "y=%d, x=%d..%d", &y, &xMin, &xMax)
-		for x := xMin; x <= xMax; x++ {
-			squares[coord{x, y}] = clay
-		}
-		if y < tyMin {
-			tyMin = y
-		}
-		if y > tyMax {
-			tyMax = y
+		if err := r.Scanf("y=%d, x=%d..%d", &y, &xMin, &xMax); err != nil {
+			return slice{}, err
 		}
-		if xMin < txMin {
-			txMin = xMin
-		}
-		if xMax > txMax {
-			txMax = xMax
+		for x := xMin; x <= xMax; x++ {
+			squares.Set(grid.Coord{X: x, Y: y}, clay)
 		}
 	}
 	return slice{
 		squares: squares,
-		xMin:    txMin,
-		xMax:    txMax,
-		yMin:    tyMin,
-		yMax:    tyMax,
-	}
+		bounds:  squares.Bounds(),
+	}, nil
 }
 
-func flow(s slice, spring coord) {
-	sources := []coord{spring}
-	checked := map[coord]struct{}{}
+// flow returns the number of tiles the water can reach
+func flow(s slice, spring grid.Coord) int {
+	sources := []grid.Coord{spring}
+	checked := map[grid.Coord]struct{}{}
 	for len(sources) > 0 {
-		var source coord
+		var source grid.Coord
 		source, sources = sources[0], sources[1:]
 		if _, ok := checked[source]; ok {
 			continue
@@ -96,44 +69,45 @@ func flow(s slice, spring coord) {
 		newSources := s.fillUp(landing)
 		sources = append(sources, newSources...)
 	}
+	return s.numWater()
 }
 
 func (s slice) numWater() int {
 	sum := 0
-	for k, v := range s.squares {
-		if k.y < s.yMin {
-			continue
+	s.squares.Each(func(c grid.Coord, v square) {
+		if c.Y < s.bounds.Min.Y {
+			return
 		}
 		if v == waterFlowing || v == waterStanding {
 			sum++
 		}
-	}
+	})
 	return sum
 }
 
 func (s slice) numWaterStanding() int {
 	sum := 0
-	for k, v := range s.squares {
-		if k.y < s.yMin {
-			continue
+	s.squares.Each(func(c grid.Coord, v square) {
+		if c.Y < s.bounds.Min.Y {
+			return
 		}
 		if v == waterStanding {
 			sum++
 		}
-	}
+	})
 	return sum
 }
 
 // water flows down until it hits clay or water
 // bool returns true if we flow out of bounds
-func (s slice) flowDown(source coord) (coord, bool) {
-	if source.y+1 > s.yMax {
-		return coord{}, true
+func (s slice) flowDown(source grid.Coord) (grid.Coord, bool) {
+	if source.Y+1 > s.bounds.Max.Y {
+		return grid.Coord{}, true
 	}
-	down := coord{source.x, source.y + 1}
-	downSquare := s.squares[down]
+	down := source.Add(grid.Down)
+	downSquare := s.squares.At(down)
 	if downSquare == sand || downSquare == waterFlowing {
-		s.squares[down] = waterFlowing
+		s.squares.Set(down, waterFlowing)
 		return s.flowDown(down)
 	}
 	return source, false
@@ -142,24 +116,24 @@ func (s slice) flowDown(source coord) (coord, bool) {
 // water flows to the left and right.
 // if it hits walls on both ends, it does the same one level up
 // if it hits a gap, create a source
-func (s slice) fillUp(landing coord) []coord {
-	s.squares[landing] = waterFlowing
+func (s slice) fillUp(landing grid.Coord) []grid.Coord {
+	s.squares.Set(landing, waterFlowing)
 	filledLeft, sourceLeft := s.fillLeft(landing)
 	filledRight, sourceRight := s.fillRight(landing)
 	// hit walls on both ends
 	if sourceLeft == nil && sourceRight == nil {
 		for _, c := range filledLeft {
-			s.squares[c] = waterStanding
+			s.squares.Set(c, waterStanding)
 		}
 		for _, c := range filledRight {
-			s.squares[c] = waterStanding
+			s.squares.Set(c, waterStanding)
 		}
-		s.squares[landing] = waterStanding
-		up := coord{landing.x, landing.y - 1}
+		s.squares.Set(landing, waterStanding)
+		up := landing.Add(grid.Up)
 		return s.fillUp(up)
 	}
 	// hit a gap on at least one end
-	sources := []coord{}
+	sources := []grid.Coord{}
 	if sourceLeft != nil {
 		sources = append(sources, *sourceLeft)
 	}
@@ -169,16 +143,16 @@ func (s slice) fillUp(landing coord) []coord {
 	return sources
 }
 
-func (s slice) fillLeft(landing coord) ([]coord, *coord) {
-	filled := []coord{}
+func (s slice) fillLeft(landing grid.Coord) ([]grid.Coord, *grid.Coord) {
+	filled := []grid.Coord{}
 	for {
-		left := coord{landing.x - 1, landing.y}
-		if s.squares[left] == clay {
+		left := landing.Add(grid.Left)
+		if s.squares.At(left) == clay {
 			return filled, nil
 		}
-		s.squares[left] = waterFlowing
-		leftDown := coord{left.x, left.y + 1}
-		squareLeftDown := s.squares[leftDown]
+		s.squares.Set(left, waterFlowing)
+		leftDown := left.Add(grid.Down)
+		squareLeftDown := s.squares.At(leftDown)
 		// found a gap, left is new source
 		if squareLeftDown == sand || squareLeftDown == waterFlowing {
 			return filled, &left
@@ -188,16 +162,16 @@ func (s slice) fillLeft(landing coord) ([]coord, *coord) {
 	}
 }
 
-func (s slice) fillRight(landing coord) ([]coord, *coord) {
-	filled := []coord{}
+func (s slice) fillRight(landing grid.Coord) ([]grid.Coord, *grid.Coord) {
+	filled := []grid.Coord{}
 	for {
-		right := coord{landing.x + 1, landing.y}
-		if s.squares[right] == clay {
+		right := landing.Add(grid.Right)
+		if s.squares.At(right) == clay {
 			return filled, nil
 		}
-		s.squares[right] = waterFlowing
-		rightDown := coord{right.x, right.y + 1}
-		squarerightDown := s.squares[rightDown]
+		s.squares.Set(right, waterFlowing)
+		rightDown := right.Add(grid.Down)
+		squarerightDown := s.squares.At(rightDown)
 		// found a gap, right is new source
 		if squarerightDown == sand || squarerightDown == waterFlowing {
 			return filled, &right
@@ -210,10 +184,10 @@ func (s slice) fillRight(landing coord) ([]coord, *coord) {
 // NOTE: catch 1: water can go beyond x bounds and still count
 func (s slice) PrintSelf() string {
 	list := []string{}
-	for y := s.yMin; y <= s.yMax; y++ {
+	for y := s.bounds.Min.Y; y <= s.bounds.Max.Y; y++ {
 		out := ""
-		for x := s.xMin - 1; x <= s.xMax+1; x++ {
-			sq := s.squares[coord{x, y}]
+		for x := s.bounds.Min.X - 1; x <= s.bounds.Max.X+1; x++ {
+			sq := s.squares.At(grid.Coord{X: x, Y: y})
 			switch sq {
 			case sand:
 				out += "."
@@ -230,14 +204,21 @@ func (s slice) PrintSelf() string {
 	return strings.Join(list, "\n")
 }
 
-func main() {
-	input, err := ioutil.ReadFile("day17.input")
+func Part1(input string) (interface{}, error) {
+	s, err := parse(input)
+	if err != nil {
+		return nil, err
+	}
+	spring := grid.Coord{X: 500, Y: 0}
+	return flow(s, spring), nil
+}
+
+func Part2(input string) (interface{}, error) {
+	s, err := parse(input)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-	s := parse(string(input))
-	spring := coord{500, 0}
+	spring := grid.Coord{X: 500, Y: 0}
 	flow(s, spring)
-	fmt.Printf("Part 1: %d\n", s.numWater())
-	fmt.Printf("Part 2: %d\n", s.numWaterStanding())
+	return s.numWaterStanding(), nil
 }
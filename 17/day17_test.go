@@ -1,9 +1,10 @@
-package main
+package day17
 
 import (
-	"math"
 	"strings"
 	"testing"
+
+	"github.com/deosjr/adventofcode2018/grid"
 )
 
 func TestFlow(t *testing.T) {
@@ -88,43 +89,25 @@ func TestFlow(t *testing.T) {
 	}
 }
 
-func testParse(input string) (slice, coord) {
-	var spring coord
-	m := map[coord]square{}
+func testParse(input string) (slice, grid.Coord) {
+	var spring grid.Coord
+	squares := grid.NewSparse[square]()
 	strip := strings.Replace(input, "\t", "", -1)
 	split := strings.Split(strip, "\n")
-
-	xMin, xMax := math.MaxInt64, math.MinInt64
-	yMin, yMax := math.MaxInt64, math.MinInt64
 	for y := 0; y < len(split); y++ {
 		for x := 0; x < len(split[0]); x++ {
 			if split[y][x] == '#' {
-				m[coord{x, y}] = clay
-				if x < xMin {
-					xMin = x
-				}
-				if x > xMax {
-					xMax = x
-				}
-				if y < yMin {
-					yMin = y
-				}
-				if y > yMax {
-					yMax = y
-				}
+				squares.Set(grid.Coord{X: x, Y: y}, clay)
 				continue
 			}
 			if split[y][x] == '+' {
-				spring = coord{x, y}
+				spring = grid.Coord{X: x, Y: y}
 			}
 		}
 	}
 	s := slice{
-		squares: m,
-		xMin:    xMin,
-		xMax:    xMax,
-		yMin:    yMin,
-		yMax:    yMax,
+		squares: squares,
+		bounds:  squares.Bounds(),
 	}
 	return s, spring
 }
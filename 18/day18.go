@@ -1,15 +1,11 @@
-package main
+package day18
 
 import (
-	"fmt"
-	"io/ioutil"
-	"strings"
+	"github.com/deosjr/adventofcode2018/cycle"
+	"github.com/deosjr/adventofcode2018/grid"
+	"github.com/deosjr/adventofcode2018/scan"
 )
 
-type coord struct {
-	x, y int
-}
-
 type acre uint8
 
 const (
@@ -18,79 +14,86 @@ const (
 	lumberyard
 )
 
-func parse(input string) map[coord]acre {
-	m := map[coord]acre{}
-	for y, s := range strings.Split(input, "\n") {
-		for x, c := range s {
+func parse(input string) (*grid.Dense[acre], error) {
+	rows := [][]acre{}
+	r := scan.NewReader(input)
+	for r.Scan() {
+		row := make([]acre, 0, len(r.Text()))
+		for x, c := range r.Text() {
 			switch c {
 			case '.':
-				m[coord{x, y}] = open
+				row = append(row, open)
 			case '|':
-				m[coord{x, y}] = wooded
+				row = append(row, wooded)
 			case '#':
-				m[coord{x, y}] = lumberyard
+				row = append(row, lumberyard)
+			default:
+				return nil, r.Errorf("map of [.|#]", "unexpected character %q at column %d", c, x+1)
 			}
 		}
+		if len(rows) > 0 && len(row) != len(rows[0]) {
+			return nil, r.Errorf("map of [.|#]", "row has length %d, expected %d", len(row), len(rows[0]))
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return grid.NewDense[acre](0, 0), nil
+	}
+	g := grid.NewDense[acre](len(rows[0]), len(rows))
+	for y, row := range rows {
+		for x, a := range row {
+			g.Set(grid.Coord{X: x, Y: y}, a)
+		}
 	}
-	return m
+	return g, nil
 }
 
-func resourceValue(grid map[coord]acre) int {
+func resourceValue(g *grid.Dense[acre]) int {
 	var wood, yards int
-	for _, v := range grid {
+	g.Each(func(_ grid.Coord, v acre) {
 		if v == wooded {
 			wood++
-			continue
+			return
 		}
 		if v == lumberyard {
 			yards++
 		}
-	}
+	})
 	return wood * yards
 }
 
-func tick(grid map[coord]acre) map[coord]acre {
-	newGrid := map[coord]acre{}
-	for y := 0; y < 50; y++ {
-		for x := 0; x < 50; x++ {
-			c := coord{x, y}
-			numWood, numYard := neighbours(c, grid)
-			switch grid[c] {
-			case open:
-				if numWood >= 3 {
-					newGrid[c] = wooded
-					continue
-				}
-				newGrid[c] = open
-			case wooded:
-				if numYard >= 3 {
-					newGrid[c] = lumberyard
-					continue
-				}
-				newGrid[c] = wooded
-			case lumberyard:
-				if numWood >= 1 && numYard >= 1 {
-					newGrid[c] = lumberyard
-					continue
-				}
-				newGrid[c] = open
+func tick(g *grid.Dense[acre]) *grid.Dense[acre] {
+	newGrid := grid.NewDense[acre](g.Width(), g.Height())
+	g.Each(func(c grid.Coord, a acre) {
+		numWood, numYard := neighbours(c, g)
+		switch a {
+		case open:
+			if numWood >= 3 {
+				newGrid.Set(c, wooded)
+				return
 			}
+			newGrid.Set(c, open)
+		case wooded:
+			if numYard >= 3 {
+				newGrid.Set(c, lumberyard)
+				return
+			}
+			newGrid.Set(c, wooded)
+		case lumberyard:
+			if numWood >= 1 && numYard >= 1 {
+				newGrid.Set(c, lumberyard)
+				return
+			}
+			newGrid.Set(c, open)
 		}
-	}
+	})
 	return newGrid
 }
 
-func neighbours(c coord, grid map[coord]acre) (int, int) {
+func neighbours(c grid.Coord, g *grid.Dense[acre]) (int, int) {
 	var numWood, numYard int
-	ncs := []coord{{c.x - 1, c.y - 1}, {c.x, c.y - 1}, {c.x + 1, c.y - 1},
-		{c.x - 1, c.y}, {c.x + 1, c.y},
-		{c.x - 1, c.y + 1}, {c.x, c.y + 1}, {c.x + 1, c.y + 1}}
-	for _, n := range ncs {
-		v, ok := grid[n]
-		if !ok {
-			continue
-		}
-		switch v {
+	for _, n := range c.Neighbours8() {
+		switch g.At(n) {
 		case wooded:
 			numWood++
 		case lumberyard:
@@ -100,49 +103,46 @@ func neighbours(c coord, grid map[coord]acre) (int, int) {
 	return numWood, numYard
 }
 
-func part1(grid map[coord]acre) int {
+func part1(g *grid.Dense[acre]) int {
 	for minute := 0; minute < 10; minute++ {
-		grid = tick(grid)
+		g = tick(g)
 	}
-	return resourceValue(grid)
+	return resourceValue(g)
 }
 
-func part2(grid map[coord]acre) int {
-	hashes := map[int]int{hash(grid): 0}
-	values := map[int]int{0: resourceValue(grid)}
-	minute := 0
-	for {
-		minute++
-		grid = tick(grid)
-		h := hash(grid)
-		if min, ok := hashes[h]; ok {
-			cycle := minute - min
-			left := (1000000000 - minute) % cycle
-			return values[min+left]
-		}
-		hashes[h] = minute
-		values[minute] = resourceValue(grid)
-	}
+// part2 finds where the landscape starts repeating itself,
+// keeping the resource value of every minute until then
+func part2(g *grid.Dense[acre]) int {
+	values := []int{resourceValue(g)}
+	c, _ := cycle.Hash(g, func(g *grid.Dense[acre]) *grid.Dense[acre] {
+		g = tick(g)
+		values = append(values, resourceValue(g))
+		return g
+	}, key)
+	return values[c.Index(1000000000)]
 }
 
-func hash(grid map[coord]acre) int {
-	sum := 0
-	for y := 0; y < 50; y++ {
-		for x := 0; x < 50; x++ {
-			sum += (y*100 + x) * int(grid[coord{x, y}])
-		}
+// key is the landscape as a string, one byte per acre
+func key(g *grid.Dense[acre]) string {
+	b := make([]byte, 0, g.Width()*g.Height())
+	g.Each(func(_ grid.Coord, a acre) {
+		b = append(b, byte(a))
+	})
+	return string(b)
+}
+
+func Part1(input string) (interface{}, error) {
+	g, err := parse(input)
+	if err != nil {
+		return nil, err
 	}
-	return sum
+	return part1(g), nil
 }
 
-func main() {
-	input, err := ioutil.ReadFile("day18.input")
+func Part2(input string) (interface{}, error) {
+	g, err := parse(input)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-	grid := parse(string(input))
-	out := part1(grid)
-	fmt.Printf("Part 1: %d\n", out)
-	out = part2(grid)
-	fmt.Printf("Part 2: %d\n", out)
+	return part2(g), nil
 }
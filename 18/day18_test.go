@@ -0,0 +1,27 @@
+package day18
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPart1(t *testing.T) {
+	input := `.#.#...|#.
+			.....#|##|
+			.|..|...#.
+			..|#.....#
+			#.#|||#|#|
+			...#.||...
+			.|....|...
+			||...#|.#|
+			|.||||..|.
+			...#.|..|.`
+	grid, err := parse(strings.Replace(input, "\t", "", -1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := part1(grid)
+	if got != 1147 {
+		t.Errorf("got %d want %d", got, 1147)
+	}
+}
@@ -0,0 +1,370 @@
+package day19
+
+import (
+	"fmt"
+	"sort"
+)
+
+// budget is the number of statements a program gets to run
+// before we go looking for a hot loop to accelerate
+const budget = 1000000
+
+// accelerateFunc replaces a loop by a closed form if it recognises it
+type accelerateFunc func(intermediateRepresentation) (intermediateRepresentation, bool)
+
+var accelerations = []accelerateFunc{divisorSum, repeatedAdd}
+
+// accelerate runs the program on the interpreter. Whenever it does not halt
+// within budget, the hottest statement is looked up and the loops around it
+// are matched against known idioms, outermost first. The first match is
+// replaced by its closed form and the program is run again from the start
+func accelerate(irlist []intermediateRepresentation, insPtr int, reg register) (register, error) {
+	for {
+		p := &profiler{counts: map[string]int{}, paths: map[string][]int{}, budget: budget}
+		out := run(p.instrument(irlist, nil), insPtr, reg)
+		if !p.exhausted {
+			return out, nil
+		}
+		hot := p.hottest()
+		accelerated := false
+		for depth := 1; depth <= len(hot) && !accelerated; depth++ {
+			path := hot[:depth]
+			for _, f := range accelerations {
+				ir, ok := f(lookup(irlist, path))
+				if ok {
+					irlist = replace(irlist, path, ir)
+					accelerated = true
+					break
+				}
+			}
+		}
+		if !accelerated {
+			return reg, fmt.Errorf("no known idiom in the loops around hot statement %v:\n%s", hot, print(irlist))
+		}
+	}
+}
+
+// profiler counts how often each statement is executed, by its path:
+// the line on the top level followed by the index in each nested block
+type profiler struct {
+	counts    map[string]int
+	paths     map[string][]int
+	steps     int
+	budget    int
+	exhausted bool
+}
+
+// counted wraps a statement to be counted before executing it.
+// Once the budget is spent it halts the program instead
+type counted struct {
+	ir   intermediateRepresentation
+	key  string
+	prof *profiler
+}
+
+func (c counted) execute(reg register) (register, next) {
+	if c.prof.steps == c.prof.budget {
+		c.prof.exhausted = true
+		return reg, halt
+	}
+	c.prof.steps++
+	c.prof.counts[c.key]++
+	return c.ir.execute(reg)
+}
+
+func (c counted) print(n int) string {
+	return c.ir.print(n)
+}
+
+func (p *profiler) instrument(irlist []intermediateRepresentation, prefix []int) []intermediateRepresentation {
+	newList := make([]intermediateRepresentation, len(irlist))
+	for i, ir := range irlist {
+		path := append(append([]int{}, prefix...), i)
+		key := fmt.Sprint(path)
+		p.paths[key] = path
+		if irs := children(ir); irs != nil {
+			ir = withChildren(ir, p.instrument(irs, path))
+		}
+		newList[i] = counted{ir: ir, key: key, prof: p}
+	}
+	return newList
+}
+
+// hottest returns the path of the statement executed most often;
+// ties go to the statement that comes first
+func (p *profiler) hottest() []int {
+	keys := make([]string, 0, len(p.counts))
+	for k := range p.counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var hot string
+	for _, k := range keys {
+		if p.counts[k] > p.counts[hot] {
+			hot = k
+		}
+	}
+	return p.paths[hot]
+}
+
+// children returns the nested block of a control structure, nil otherwise
+func children(ir intermediateRepresentation) []intermediateRepresentation {
+	switch t := ir.(type) {
+	case ifstatement:
+		return t.irs
+	case doWhile:
+		return t.irs
+	case forLoop:
+		return t.irs
+	}
+	return nil
+}
+
+func withChildren(ir intermediateRepresentation, irs []intermediateRepresentation) intermediateRepresentation {
+	switch t := ir.(type) {
+	case ifstatement:
+		t.irs = irs
+		return t
+	case doWhile:
+		t.irs = irs
+		return t
+	case forLoop:
+		t.irs = irs
+		return t
+	}
+	return ir
+}
+
+func lookup(irlist []intermediateRepresentation, path []int) intermediateRepresentation {
+	ir := irlist[path[0]]
+	if len(path) == 1 {
+		return ir
+	}
+	return lookup(children(ir), path[1:])
+}
+
+func replace(irlist []intermediateRepresentation, path []int, ir intermediateRepresentation) []intermediateRepresentation {
+	newList := append([]intermediateRepresentation{}, irlist...)
+	if len(path) > 1 {
+		parent := irlist[path[0]]
+		ir = withChildren(parent, replace(children(parent), path[1:], ir))
+	}
+	newList[path[0]] = ir
+	return newList
+}
+
+// assigns reports whether any statement in irlist writes to register x
+func assigns(irlist []intermediateRepresentation, x r) bool {
+	for _, ir := range irlist {
+		switch t := ir.(type) {
+		case assignment:
+			if t.assignee == x {
+				return true
+			}
+		case assignmentOp:
+			if t.assignee == x {
+				return true
+			}
+		case comparison:
+			if t.r == x {
+				return true
+			}
+		case ifGotos:
+			if flagged(t.flag, x) {
+				return true
+			}
+		case ifstatement:
+			if flagged(t.flag, x) || assigns(t.irs, x) {
+				return true
+			}
+		case doWhile:
+			if flagged(t.flag, x) || assigns(t.irs, x) {
+				return true
+			}
+		case forLoop:
+			if t.loopvar == x || flagged(t.flag, x) || assigns(t.irs, x) {
+				return true
+			}
+		case goTo, indirectJump, end:
+		default:
+			// unknown statement, assume the worst
+			return true
+		}
+	}
+	return false
+}
+
+func flagged(flag *r, x r) bool {
+	return flag != nil && *flag == x
+}
+
+// invariant reports whether value does not change while irlist runs
+func invariant(value rOrv, irlist []intermediateRepresentation) bool {
+	switch t := value.(type) {
+	case v:
+		return true
+	case r:
+		return !assigns(irlist, t)
+	}
+	return false
+}
+
+// sumOfDivisors is the closed form of
+//
+//	for a=1;a > n;a++ {
+//		for b=1;b > n;b++ {
+//			t = a * b
+//			if t == n {
+//				sum += a
+//			}
+//		}
+//	}
+//
+// where the comparisons may leave their result in t
+type sumOfDivisors struct {
+	sum, a, b, t r
+	n            rOrv
+	// whether t holds the result of the check or of a loop condition
+	checkFlag, loopFlag bool
+}
+
+func divisorSum(ir intermediateRepresentation) (intermediateRepresentation, bool) {
+	outer, ok := ir.(forLoop)
+	if !ok || outer.init != (v{1}) || len(outer.irs) != 1 {
+		return nil, false
+	}
+	inner, ok := outer.irs[0].(forLoop)
+	if !ok || inner.init != (v{1}) || len(inner.irs) != 2 {
+		return nil, false
+	}
+	a, b, n := outer.loopvar, inner.loopvar, outer.cond.y
+	if outer.cond != (op{">", a, n}) || inner.cond != (op{">", b, n}) {
+		return nil, false
+	}
+	product, ok := inner.irs[0].(assignment)
+	if !ok || (product.value != (op{"*", a, b}) && product.value != (op{"*", b, a})) {
+		return nil, false
+	}
+	t := product.assignee
+	check, ok := inner.irs[1].(ifstatement)
+	if !ok || (check.cond != (op{"==", t, n}) && check.cond != (op{"==", n, t})) || len(check.irs) != 1 {
+		return nil, false
+	}
+	add, ok := check.irs[0].(assignmentOp)
+	if !ok || add.operator != "+" || add.value != a {
+		return nil, false
+	}
+	sum := add.assignee
+	for _, flag := range []*r{outer.flag, inner.flag, check.flag} {
+		if flag != nil && *flag != t {
+			return nil, false
+		}
+	}
+	registers := map[r]bool{a: true, b: true, t: true, sum: true}
+	if len(registers) != 4 || !invariant(n, outer.irs) {
+		return nil, false
+	}
+	if nr, ok := n.(r); ok && registers[nr] {
+		return nil, false
+	}
+	return sumOfDivisors{sum: sum, a: a, b: b, t: t, n: n,
+		checkFlag: check.flag != nil, loopFlag: outer.flag != nil || inner.flag != nil}, true
+}
+
+func (s sumOfDivisors) execute(reg register) (register, next) {
+	n := s.n.value(reg)
+	// both loops run at least once, even if n < 1
+	last := 1
+	if n >= 1 {
+		last = n
+		for d := 1; d*d <= n; d++ {
+			if n%d != 0 {
+				continue
+			}
+			reg[s.sum.i] += d
+			if d != n/d {
+				reg[s.sum.i] += n / d
+			}
+		}
+	}
+	reg[s.a.i], reg[s.b.i], reg[s.t.i] = last+1, last+1, last*last
+	if s.checkFlag {
+		reg[s.t.i] = boolToInt(last*last == n)
+	}
+	if s.loopFlag {
+		// both loops end on their condition being true
+		reg[s.t.i] = 1
+	}
+	return reg, fallthru
+}
+
+func (s sumOfDivisors) print(n int) string {
+	return fmt.Sprintf("%s%s += sum of divisors of %s", indent(n), s.sum.print(), s.n.print())
+}
+
+// additions is the closed form of a for loop that only adds
+// values which do not change in the loop
+//
+//	for x=init;x > bound;x++ {
+//		y += z
+//	}
+type additions struct {
+	loopvar     r
+	init, bound rOrv
+	flag        *r
+	adds        []assignmentOp
+}
+
+func repeatedAdd(ir intermediateRepresentation) (intermediateRepresentation, bool) {
+	fl, ok := ir.(forLoop)
+	if !ok || fl.cond != (op{">", fl.loopvar, fl.cond.y}) {
+		return nil, false
+	}
+	body := append([]intermediateRepresentation{}, fl.irs...)
+	body = append(body, assignmentOp{"+", fl.loopvar, v{1}})
+	if fl.flag != nil {
+		if *fl.flag == fl.loopvar {
+			return nil, false
+		}
+		body = append(body, comparison{fl.cond, *fl.flag})
+	}
+	if !invariant(fl.cond.y, body) {
+		return nil, false
+	}
+	adds := make([]assignmentOp, len(fl.irs))
+	for i, ir := range fl.irs {
+		a, ok := ir.(assignmentOp)
+		if !ok || a.operator != "+" || a.assignee == fl.loopvar || flagged(fl.flag, a.assignee) || !invariant(a.value, body) {
+			return nil, false
+		}
+		adds[i] = a
+	}
+	return additions{loopvar: fl.loopvar, init: fl.init, bound: fl.cond.y, flag: fl.flag, adds: adds}, true
+}
+
+func (a additions) execute(reg register) (register, next) {
+	init := a.init.value(reg)
+	// the body runs at least once
+	trips := a.bound.value(reg) - init + 1
+	if trips < 1 {
+		trips = 1
+	}
+	for _, add := range a.adds {
+		reg[add.assignee.i] += trips * add.value.value(reg)
+	}
+	reg[a.loopvar.i] = init + trips
+	if a.flag != nil {
+		// the loop ends on its condition being true
+		reg[a.flag.i] = 1
+	}
+	return reg, fallthru
+}
+
+func (a additions) print(n int) string {
+	s := indent(n)
+	s += fmt.Sprintf("for %s=%s;%s;%s++ in closed form {", a.loopvar.print(), a.init.print(), op{">", a.loopvar, a.bound}.print(), a.loopvar.print())
+	for _, add := range a.adds {
+		s += "\n" + add.print(n+1)
+	}
+	return s + "\n" + indent(n) + "}"
+}
@@ -0,0 +1,197 @@
+package day19
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+func TestAccelerate(t *testing.T) {
+	input, err := ioutil.ReadFile("day19.input")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := parse(string(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		r0   int
+		want int
+	}{
+		{r0: 0, want: 1922},
+		{r0: 1, want: 22302144},
+	} {
+		var reg register
+		reg[0] = tt.r0
+		got, err := accelerate(lift(p.IPRegister, p.Instructions), p.IPRegister, reg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got[0] != tt.want {
+			t.Errorf("r0=%d: got %d want %d", tt.r0, got[0], tt.want)
+		}
+	}
+}
+
+func TestAccelerateUnknownIdiom(t *testing.T) {
+	// r0 += 1 forever
+	irlist := []intermediateRepresentation{
+		goTo{1},
+		assignmentOp{"+", r{0}, v{1}},
+		goTo{1},
+	}
+	if _, err := accelerate(irlist, 5, register{}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+// TestClosedForms checks each closed form against the loop it replaces
+func TestClosedForms(t *testing.T) {
+	divisors := forLoop{
+		loopvar: r{1},
+		init:    v{1},
+		cond:    op{">", r{1}, r{2}},
+		irs: []intermediateRepresentation{forLoop{
+			loopvar: r{5},
+			init:    v{1},
+			cond:    op{">", r{5}, r{2}},
+			irs: []intermediateRepresentation{
+				assignment{r{3}, op{"*", r{1}, r{5}}},
+				ifstatement{
+					cond: op{"==", r{3}, r{2}},
+					irs:  []intermediateRepresentation{assignmentOp{"+", r{0}, r{1}}},
+				},
+			},
+		}},
+	}
+	adds := forLoop{
+		loopvar: r{1},
+		init:    r{4},
+		cond:    op{">", r{1}, r{2}},
+		irs: []intermediateRepresentation{
+			assignmentOp{"+", r{0}, r{3}},
+			assignmentOp{"+", r{5}, v{2}},
+		},
+	}
+	// the same loops keeping the results of their comparisons, as in day 19
+	flaggedDivisors := divisors
+	flaggedDivisors.flag = &r{3}
+	inner := divisors.irs[0].(forLoop)
+	inner.flag = &r{3}
+	check := inner.irs[1].(ifstatement)
+	check.flag = &r{3}
+	inner.irs = []intermediateRepresentation{inner.irs[0], check}
+	flaggedDivisors.irs = []intermediateRepresentation{inner}
+	flaggedAdds := adds
+	flaggedAdds.flag = &r{4}
+	for _, tt := range []struct {
+		name string
+		loop forLoop
+		f    accelerateFunc
+	}{
+		{"divisorSum", divisors, divisorSum},
+		{"repeatedAdd", adds, repeatedAdd},
+		{"divisorSum with flags", flaggedDivisors, divisorSum},
+		{"repeatedAdd with flag", flaggedAdds, repeatedAdd},
+	} {
+		ir, ok := tt.f(tt.loop)
+		if !ok {
+			t.Fatalf("%s: loop not recognised", tt.name)
+		}
+		for _, n := range []int{-3, 0, 1, 2, 7, 12, 36, 97} {
+			reg := register{3, 0, n, 5, 2, 1}
+			want, _ := tt.loop.execute(reg)
+			got, _ := ir.execute(reg)
+			if got != want {
+				t.Errorf("%s(%d): got %v want %v", tt.name, n, got, want)
+			}
+		}
+	}
+}
+
+func TestNotAccelerated(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		loop forLoop
+		f    accelerateFunc
+	}{
+		{
+			name: "bound changes",
+			loop: forLoop{
+				loopvar: r{1},
+				init:    v{0},
+				cond:    op{">", r{1}, r{2}},
+				irs:     []intermediateRepresentation{assignmentOp{"+", r{2}, v{1}}},
+			},
+			f: repeatedAdd,
+		},
+		{
+			name: "adds a changing value",
+			loop: forLoop{
+				loopvar: r{1},
+				init:    v{0},
+				cond:    op{">", r{1}, r{2}},
+				irs:     []intermediateRepresentation{assignmentOp{"+", r{0}, r{1}}},
+			},
+			f: repeatedAdd,
+		},
+		{
+			name: "inner loop starts at 0",
+			loop: forLoop{
+				loopvar: r{1},
+				init:    v{1},
+				cond:    op{">", r{1}, r{2}},
+				irs: []intermediateRepresentation{forLoop{
+					loopvar: r{5},
+					init:    v{0},
+					cond:    op{">", r{5}, r{2}},
+					irs: []intermediateRepresentation{
+						assignment{r{3}, op{"*", r{1}, r{5}}},
+						ifstatement{
+							cond: op{"==", r{3}, r{2}},
+							irs:  []intermediateRepresentation{assignmentOp{"+", r{0}, r{1}}},
+						},
+					},
+				}},
+			},
+			f: divisorSum,
+		},
+		{
+			name: "flag is added to",
+			loop: forLoop{
+				loopvar: r{1},
+				init:    v{0},
+				cond:    op{">", r{1}, r{2}},
+				flag:    &r{0},
+				irs:     []intermediateRepresentation{assignmentOp{"+", r{0}, v{1}}},
+			},
+			f: repeatedAdd,
+		},
+		{
+			name: "flag is not t",
+			loop: forLoop{
+				loopvar: r{1},
+				init:    v{1},
+				cond:    op{">", r{1}, r{2}},
+				flag:    &r{4},
+				irs: []intermediateRepresentation{forLoop{
+					loopvar: r{5},
+					init:    v{1},
+					cond:    op{">", r{5}, r{2}},
+					irs: []intermediateRepresentation{
+						assignment{r{3}, op{"*", r{1}, r{5}}},
+						ifstatement{
+							cond: op{"==", r{3}, r{2}},
+							irs:  []intermediateRepresentation{assignmentOp{"+", r{0}, r{1}}},
+						},
+					},
+				}},
+			},
+			f: divisorSum,
+		},
+	} {
+		if _, ok := tt.f(tt.loop); ok {
+			t.Errorf("%s: should not be accelerated", tt.name)
+		}
+	}
+}
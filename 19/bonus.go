@@ -1,27 +1,26 @@
-package main
+package day19
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/deosjr/adventofcode2018/elfcode"
+)
 
 // bonus: do the optimisation itself in code
 // - rewrite program to list of optimised instructions
 // - execute optimised program
 // option 1: run the intermediate representation in Go (interpreter)
 // option 2: write the equivalent Go code, go build, go run (compiler)
-func bonus(insPtr int, program []instruction) string {
-	irlist := make([]intermediateRepresentation, len(program))
-	for i, p := range program {
-		irlist[i] = p.opcode.translate(p, i, insPtr)
-	}
-	irlist = rewriteOperators(irlist, insPtr)
-	irlist = rewritePointer(irlist, insPtr)
-	irlist = compile(irlist, insPtr)
-	// fmt.Println(print(irlist))
-	return "unfinished"
+// bonus does option 2, see codegen.go. option 1 is in interpret.go
+func bonus(insPtr int, program []elfcode.Instruction) (string, error) {
+	return generate(lift(insPtr, program), insPtr, len(program), 6, "run")
 }
 
 func compile(irlist []intermediateRepresentation, insPtr int) []intermediateRepresentation {
 	// fmt.Println(print(irlist))
 	// fmt.Println("========================")
+	// indirect jumps can land on any line, including ones folded into
+	// control structures here: those refuse at runtime, see relocate
 	rewrites := []rewriteFunc{ifelsePattern, singleIf, forloop, dowhile}
 	for {
 		for _, f := range rewrites {
@@ -35,173 +34,211 @@ func compile(irlist []intermediateRepresentation, insPtr int) []intermediateRepr
 	return irlist
 }
 
-func (addr) translate(instr instruction, lineNumber, insPtr int) intermediateRepresentation {
+type translateFunc func(instr elfcode.Instruction, lineNumber, insPtr int) intermediateRepresentation
+
+var translations = map[string]translateFunc{"addr": translateAddr, "addi": translateAddi, "mulr": translateMulr, "muli": translateMuli,
+	"banr": translateBanr, "bani": translateBani, "borr": translateBorr, "bori": translateBori, "setr": translateSetr, "seti": translateSeti,
+	"gtir": translateGtir, "gtri": translateGtri, "gtrr": translateGtrr, "eqir": translateEqir, "eqri": translateEqri, "eqrr": translateEqrr}
+
+// translate turns a single instruction into intermediate representation
+func translate(instr elfcode.Instruction, lineNumber, insPtr int) intermediateRepresentation {
+	return translations[instr.Op.Name](instr, lineNumber, insPtr)
+}
+
+// read returns the operand for reading register i on line lineNumber:
+// the pointer register always holds the current line number
+func read(i, lineNumber, insPtr int) rOrv {
+	if i == insPtr {
+		return v{lineNumber}
+	}
+	return r{i}
+}
+
+// jumpTo returns the jump for writing value to the pointer register:
+// a goto if value is known at compile time, an indirect jump otherwise
+func jumpTo(value rOrv) intermediateRepresentation {
+	switch t := value.(type) {
+	case v:
+		return goTo{t.i + 1}
+	case op:
+		x, xok := t.x.(v)
+		y, yok := t.y.(v)
+		if xok && yok {
+			return goTo{apply(t.operator, x.i, y.i) + 1}
+		}
+	}
+	return indirectJump{target: value}
+}
+
+func translateAddr(instr elfcode.Instruction, lineNumber, insPtr int) intermediateRepresentation {
+	if instr.C == insPtr {
+		return jumpTo(op{"+", read(instr.A, lineNumber, insPtr), read(instr.B, lineNumber, insPtr)})
+	}
 	return assignment{
-		assignee: r{instr.c},
-		value:    op{"+", r{instr.a}, r{instr.b}},
+		assignee: r{instr.C},
+		value:    op{"+", read(instr.A, lineNumber, insPtr), read(instr.B, lineNumber, insPtr)},
 	}
 }
 
-func (addi) translate(instr instruction, lineNumber, insPtr int) intermediateRepresentation {
-	if instr.a == insPtr && instr.c == insPtr {
-		return goTo{lineNumber + 1 + instr.b}
+func translateAddi(instr elfcode.Instruction, lineNumber, insPtr int) intermediateRepresentation {
+	if instr.C == insPtr {
+		return jumpTo(op{"+", read(instr.A, lineNumber, insPtr), v{instr.B}})
 	}
 	return assignment{
-		assignee: r{instr.c},
-		value:    op{"+", r{instr.a}, v{instr.b}},
+		assignee: r{instr.C},
+		value:    op{"+", read(instr.A, lineNumber, insPtr), v{instr.B}},
 	}
 }
 
-func (mulr) translate(instr instruction, lineNumber, insPtr int) intermediateRepresentation {
-	if instr.c == insPtr {
-		// TODO: this is not always valid.
-		// Good enough for now though
-		return end{}
+func translateMulr(instr elfcode.Instruction, lineNumber, insPtr int) intermediateRepresentation {
+	if instr.C == insPtr {
+		return jumpTo(op{"*", read(instr.A, lineNumber, insPtr), read(instr.B, lineNumber, insPtr)})
 	}
 	return assignment{
-		assignee: r{instr.c},
-		value:    op{"*", r{instr.a}, r{instr.b}},
+		assignee: r{instr.C},
+		value:    op{"*", read(instr.A, lineNumber, insPtr), read(instr.B, lineNumber, insPtr)},
 	}
 }
 
-func (muli) translate(instr instruction, lineNumber, insPtr int) intermediateRepresentation {
-	if instr.c == insPtr {
-		panic("unimplemented")
+func translateMuli(instr elfcode.Instruction, lineNumber, insPtr int) intermediateRepresentation {
+	if instr.C == insPtr {
+		return jumpTo(op{"*", read(instr.A, lineNumber, insPtr), v{instr.B}})
 	}
 	return assignment{
-		assignee: r{instr.c},
-		value:    op{"*", r{instr.a}, v{instr.b}},
+		assignee: r{instr.C},
+		value:    op{"*", read(instr.A, lineNumber, insPtr), v{instr.B}},
 	}
 }
 
-func (banr) translate(instr instruction, lineNumber, insPtr int) intermediateRepresentation {
-	if instr.c == insPtr {
-		panic("unimplemented")
+func translateBanr(instr elfcode.Instruction, lineNumber, insPtr int) intermediateRepresentation {
+	if instr.C == insPtr {
+		return jumpTo(op{"&", read(instr.A, lineNumber, insPtr), read(instr.B, lineNumber, insPtr)})
 	}
 	return assignment{
-		assignee: r{instr.c},
-		value:    op{"&", r{instr.a}, r{instr.b}},
+		assignee: r{instr.C},
+		value:    op{"&", read(instr.A, lineNumber, insPtr), read(instr.B, lineNumber, insPtr)},
 	}
 }
 
-func (bani) translate(instr instruction, lineNumber, insPtr int) intermediateRepresentation {
-	if instr.c == insPtr {
-		panic("unimplemented")
+func translateBani(instr elfcode.Instruction, lineNumber, insPtr int) intermediateRepresentation {
+	if instr.C == insPtr {
+		return jumpTo(op{"&", read(instr.A, lineNumber, insPtr), v{instr.B}})
 	}
 	return assignment{
-		assignee: r{instr.c},
-		value:    op{"&", r{instr.a}, v{instr.b}},
+		assignee: r{instr.C},
+		value:    op{"&", read(instr.A, lineNumber, insPtr), v{instr.B}},
 	}
 }
 
-func (borr) translate(instr instruction, lineNumber, insPtr int) intermediateRepresentation {
-	if instr.c == insPtr {
-		panic("unimplemented")
+func translateBorr(instr elfcode.Instruction, lineNumber, insPtr int) intermediateRepresentation {
+	if instr.C == insPtr {
+		return jumpTo(op{"|", read(instr.A, lineNumber, insPtr), read(instr.B, lineNumber, insPtr)})
 	}
 	return assignment{
-		assignee: r{instr.c},
-		value:    op{"|", r{instr.a}, r{instr.b}},
+		assignee: r{instr.C},
+		value:    op{"|", read(instr.A, lineNumber, insPtr), read(instr.B, lineNumber, insPtr)},
 	}
 }
 
-func (bori) translate(instr instruction, lineNumber, insPtr int) intermediateRepresentation {
-	if instr.c == insPtr {
-		panic("unimplemented")
+func translateBori(instr elfcode.Instruction, lineNumber, insPtr int) intermediateRepresentation {
+	if instr.C == insPtr {
+		return jumpTo(op{"|", read(instr.A, lineNumber, insPtr), v{instr.B}})
 	}
 	return assignment{
-		assignee: r{instr.c},
-		value:    op{"|", r{instr.a}, v{instr.b}},
+		assignee: r{instr.C},
+		value:    op{"|", read(instr.A, lineNumber, insPtr), v{instr.B}},
 	}
 }
 
-func (setr) translate(instr instruction, lineNumber, insPtr int) intermediateRepresentation {
-	if instr.c == insPtr {
-		panic("unimplemented")
+func translateSetr(instr elfcode.Instruction, lineNumber, insPtr int) intermediateRepresentation {
+	if instr.C == insPtr {
+		return jumpTo(read(instr.A, lineNumber, insPtr))
 	}
 	return assignment{
-		assignee: r{instr.c},
-		value:    r{instr.a},
+		assignee: r{instr.C},
+		value:    read(instr.A, lineNumber, insPtr),
 	}
 }
 
-func (seti) translate(instr instruction, lineNumber, insPtr int) intermediateRepresentation {
-	if instr.c == insPtr {
-		return goTo{instr.a + 1}
+func translateSeti(instr elfcode.Instruction, lineNumber, insPtr int) intermediateRepresentation {
+	if instr.C == insPtr {
+		return jumpTo(v{instr.A})
 	}
 	return assignment{
-		assignee: r{instr.c},
-		value:    v{instr.a},
+		assignee: r{instr.C},
+		value:    v{instr.A},
 	}
 }
 
-func (gtir) translate(instr instruction, lineNumber, insPtr int) intermediateRepresentation {
-	if instr.c == insPtr {
-		panic("unimplemented")
+func translateGtir(instr elfcode.Instruction, lineNumber, insPtr int) intermediateRepresentation {
+	if instr.C == insPtr {
+		return jumpTo(op{">", v{instr.A}, read(instr.B, lineNumber, insPtr)})
 	}
 	return comparison{
-		cond: op{">", v{instr.a}, r{instr.b}},
-		r:    r{instr.c},
+		cond: op{">", v{instr.A}, read(instr.B, lineNumber, insPtr)},
+		r:    r{instr.C},
 	}
 }
 
-func (gtri) translate(instr instruction, lineNumber, insPtr int) intermediateRepresentation {
-	if instr.c == insPtr {
-		panic("unimplemented")
+func translateGtri(instr elfcode.Instruction, lineNumber, insPtr int) intermediateRepresentation {
+	if instr.C == insPtr {
+		return jumpTo(op{">", read(instr.A, lineNumber, insPtr), v{instr.B}})
 	}
 	return comparison{
-		cond: op{">", r{instr.a}, v{instr.b}},
-		r:    r{instr.c},
+		cond: op{">", read(instr.A, lineNumber, insPtr), v{instr.B}},
+		r:    r{instr.C},
 	}
 }
 
-func (gtrr) translate(instr instruction, lineNumber, insPtr int) intermediateRepresentation {
-	if instr.c == insPtr {
-		panic("unimplemented")
+func translateGtrr(instr elfcode.Instruction, lineNumber, insPtr int) intermediateRepresentation {
+	if instr.C == insPtr {
+		return jumpTo(op{">", read(instr.A, lineNumber, insPtr), read(instr.B, lineNumber, insPtr)})
 	}
 	return comparison{
-		cond: op{">", r{instr.a}, r{instr.b}},
-		r:    r{instr.c},
+		cond: op{">", read(instr.A, lineNumber, insPtr), read(instr.B, lineNumber, insPtr)},
+		r:    r{instr.C},
 	}
 }
 
-func (eqir) translate(instr instruction, lineNumber, insPtr int) intermediateRepresentation {
-	if instr.c == insPtr {
-		panic("unimplemented")
+func translateEqir(instr elfcode.Instruction, lineNumber, insPtr int) intermediateRepresentation {
+	if instr.C == insPtr {
+		return jumpTo(op{"==", v{instr.A}, read(instr.B, lineNumber, insPtr)})
 	}
 	return comparison{
-		cond: op{"==", v{instr.a}, r{instr.b}},
-		r:    r{instr.c},
+		cond: op{"==", v{instr.A}, read(instr.B, lineNumber, insPtr)},
+		r:    r{instr.C},
 	}
 }
 
-func (eqri) translate(instr instruction, lineNumber, insPtr int) intermediateRepresentation {
-	if instr.c == insPtr {
-		panic("unimplemented")
+func translateEqri(instr elfcode.Instruction, lineNumber, insPtr int) intermediateRepresentation {
+	if instr.C == insPtr {
+		return jumpTo(op{"==", read(instr.A, lineNumber, insPtr), v{instr.B}})
 	}
 	return comparison{
-		cond: op{"==", r{instr.a}, v{instr.b}},
-		r:    r{instr.c},
+		cond: op{"==", read(instr.A, lineNumber, insPtr), v{instr.B}},
+		r:    r{instr.C},
 	}
 }
 
-func (eqrr) translate(instr instruction, lineNumber, insPtr int) intermediateRepresentation {
-	if instr.c == insPtr {
-		panic("unimplemented")
+func translateEqrr(instr elfcode.Instruction, lineNumber, insPtr int) intermediateRepresentation {
+	if instr.C == insPtr {
+		return jumpTo(op{"==", read(instr.A, lineNumber, insPtr), read(instr.B, lineNumber, insPtr)})
 	}
 	return comparison{
-		cond: op{"==", r{instr.a}, r{instr.b}},
-		r:    r{instr.c},
+		cond: op{"==", read(instr.A, lineNumber, insPtr), read(instr.B, lineNumber, insPtr)},
+		r:    r{instr.C},
 	}
 }
 
 // intermediateRepresentation interface covers simple statements
 // but also more complex control structures such as for loops
 type intermediateRepresentation interface {
-	// execute(register) register
+	execute(register) (register, next)
 	print(indent int) string
 }
 
 type rOrv interface {
+	value(register) int
 	print() string
 }
 
@@ -273,6 +310,62 @@ func (g goTo) print(n int) string {
 	return fmt.Sprintf("goto %d", g.lineNumber)
 }
 
+// indirectJump sets the pointer register to a value only known at runtime,
+// continuing at the line after it. The target is a line in the original
+// program, shifts keep track of lines inserted or deleted since
+type indirectJump struct {
+	target rOrv
+	shifts []shift
+}
+
+type shift struct {
+	line, n int
+}
+
+func (ij indirectJump) print(n int) string {
+	return fmt.Sprintf("goto %s + 1", ij.target.print())
+}
+
+// relocate maps a line in the original program to the current one,
+// the same way updateGoto moves gotos. It returns false if the line
+// has been folded into a control structure, leaving nothing to jump to
+func (ij indirectJump) relocate(line int) (int, bool) {
+	for _, s := range ij.shifts {
+		if s.n < 0 && line > s.line && line <= s.line-s.n {
+			return 0, false
+		}
+		if line > s.line {
+			line += s.n
+		}
+	}
+	return line, true
+}
+
+// relative returns rX if the jump is on line and jumps rX lines ahead
+// of it, as the puzzles do with addr P X P
+func (ij indirectJump) relative(line int) (r, bool) {
+	o, ok := ij.target.(op)
+	if !ok || o.operator != "+" {
+		return r{}, false
+	}
+	x, y := o.x, o.y
+	if _, ok := x.(r); ok {
+		x, y = y, x
+	}
+	from, ok := x.(v)
+	if !ok {
+		return r{}, false
+	}
+	reg, ok := y.(r)
+	if !ok {
+		return r{}, false
+	}
+	if l, ok := ij.relocate(from.i); !ok || l != line {
+		return r{}, false
+	}
+	return reg, true
+}
+
 type end struct {
 	lineNumber int
 }
@@ -292,20 +385,31 @@ func (c comparison) print(n int) string {
 	return s
 }
 
+// control structures keep writing the result of the comparison they were
+// folded from to its register: flag, which is nil if there is none
 type ifGotos struct {
 	cond  op
+	flag  *r
 	ifg   goTo
 	elseg goTo
 }
 
 func (i ifGotos) print(n int) string {
 	s := indent(n)
-	s = fmt.Sprintf("if %s {\n%s\t%s\n%s} else {\n%s\t%s\n%s}", i.cond.print(), s, i.ifg.print(0), s, s, i.elseg.print(0), s)
+	s = fmt.Sprintf("if %s {\n%s\t%s\n%s} else {\n%s\t%s\n%s}", printCond(i.cond, i.flag), s, i.ifg.print(0), s, s, i.elseg.print(0), s)
 	return s
 }
 
+func printCond(cond op, flag *r) string {
+	if flag == nil {
+		return cond.print()
+	}
+	return fmt.Sprintf("(%s = %s)", flag.print(), cond.print())
+}
+
 type ifstatement struct {
 	cond op
+	flag *r
 	irs  []intermediateRepresentation
 }
 
@@ -315,12 +419,13 @@ func (i ifstatement) print(n int) string {
 	for _, ir := range i.irs {
 		irStr += ir.print(n+1) + "\n"
 	}
-	s = fmt.Sprintf("%sif %s {\n%s%s}", s, i.cond.print(), irStr, s)
+	s = fmt.Sprintf("%sif %s {\n%s%s}", s, printCond(i.cond, i.flag), irStr, s)
 	return s
 }
 
 type doWhile struct {
 	cond op
+	flag *r
 	irs  []intermediateRepresentation
 }
 
@@ -330,7 +435,7 @@ func (dw doWhile) print(n int) string {
 	for _, ir := range dw.irs {
 		irStr += ir.print(n+1) + "\n"
 	}
-	s = fmt.Sprintf("%sdo {\n%s%s} while !(%s)", s, irStr, s, dw.cond.print())
+	s = fmt.Sprintf("%sdo {\n%s%s} while !(%s)", s, irStr, s, printCond(dw.cond, dw.flag))
 	return s
 }
 
@@ -338,6 +443,7 @@ type forLoop struct {
 	loopvar r
 	init    rOrv
 	cond    op
+	flag    *r
 	irs     []intermediateRepresentation
 }
 
@@ -347,54 +453,12 @@ func (fl forLoop) print(n int) string {
 	for _, ir := range fl.irs {
 		irStr += ir.print(n+1) + "\n"
 	}
-	s = fmt.Sprintf("%sfor %s=%s;%s;%s++ {\n%s%s}", s, fl.loopvar.print(), fl.init.print(), fl.cond.print(), fl.loopvar.print(), irStr, s)
+	s = fmt.Sprintf("%sfor %s=%s;%s;%s++ {\n%s%s}", s, fl.loopvar.print(), fl.init.print(), printCond(fl.cond, fl.flag), fl.loopvar.print(), irStr, s)
 	return s
 }
 
 type rewriteFunc func([]intermediateRepresentation, int) ([]intermediateRepresentation, bool)
 
-// TODO: for the case rP += rX we need to use an assumption:
-// rX at this point can only be 0 or 1
-// this happens in _our_ case but will not extend to the general case at all
-// However, _if_ this is the case, then addr P X P (rP += rX) 'simplifies' to:
-// eqri X 1 X
-// addr P X P
-func rewritePointer(irlist []intermediateRepresentation, insPtr int) []intermediateRepresentation {
-	updates := map[int]int{}
-	newList := []intermediateRepresentation{irlist[0]}
-	for line, ir := range irlist[1:] {
-		a, ok := ir.(assignmentOp)
-		if !ok {
-			newList = append(newList, ir)
-			continue
-		}
-		if _, ok := irlist[line].(comparison); ok {
-			newList = append(newList, ir)
-			continue
-		}
-		if a.operator != "+" {
-			newList = append(newList, ir)
-			continue
-		}
-		if a.assignee.i != insPtr {
-			newList = append(newList, ir)
-			continue
-		}
-		r, ok := a.value.(r)
-		if !ok {
-			newList = append(newList, ir)
-			continue
-		}
-		newList = append(newList, eqri{}.translate(instruction{eqri{}, r.i, 1, r.i}, line, insPtr))
-		newList = append(newList, assignmentOp{"+", a.assignee, r})
-		updates[line] = 1
-	}
-	for k, v := range updates {
-		newList = updateGotos(newList, k, v)
-	}
-	return newList
-}
-
 // TODO: assignments to 0*X or rX+0 can be simplified
 func rewriteOperators(irlist []intermediateRepresentation, insPtr int) []intermediateRepresentation {
 	canBeDeleted := map[int]struct{}{}
@@ -439,7 +503,7 @@ func rewriteOperators(irlist []intermediateRepresentation, insPtr int) []interme
 }
 
 // N:   rX = comparison (so rX is either 1 or 0)
-// N+1: rP += rX (pointer register +1 depending on comparison)
+// N+1: goto N+1 + rX + 1 (skip the next line depending on comparison)
 // N+2: goto Y
 // N+3: some other statement
 // ------------------------
@@ -447,31 +511,32 @@ func rewriteOperators(irlist []intermediateRepresentation, insPtr int) []interme
 // 	goto N+3
 // }
 // goto Y
+// Only the comparison right before the jump makes rX a boolean: any other
+// jump relative to the pointer register stays an indirect jump
 func ifelsePattern(irlist []intermediateRepresentation, insPtr int) ([]intermediateRepresentation, bool) {
 	for line, ir := range irlist[:len(irlist)-2] {
 		c, ok := ir.(comparison)
 		if !ok {
 			continue
 		}
-		a, ok := irlist[line+1].(assignmentOp)
+		ij, ok := irlist[line+1].(indirectJump)
 		if !ok {
 			continue
 		}
-		if a.operator != "+" {
-			continue
-		}
-		if a.assignee.i != insPtr {
-			continue
-		}
-		if c.r != a.value {
+		if x, ok := ij.relative(line + 1); !ok || c.r != x {
 			continue
 		}
 		g, ok := irlist[line+2].(goTo)
 		if !ok {
 			continue
 		}
+		if jumpsInto(irlist, line, line+2) {
+			continue
+		}
+		flag := c.r
 		newIR := ifGotos{
 			cond:  c.cond,
+			flag:  &flag,
 			ifg:   goTo{line + 3},
 			elseg: g,
 		}
@@ -494,9 +559,10 @@ func singleIf(irlist []intermediateRepresentation, insPtr int) ([]intermediateRe
 		if !ok {
 			continue
 		}
-		if igt.ifg.lineNumber == line+1 && igt.elseg.lineNumber == line+2 {
+		if igt.ifg.lineNumber == line+1 && igt.elseg.lineNumber == line+2 && !jumpsInto(irlist, line, line+1) {
 			newIR := ifstatement{
 				cond: igt.cond,
+				flag: igt.flag,
 				irs:  []intermediateRepresentation{irlist[line+1]},
 			}
 			return updateList(newIR, irlist, line, 1), true
@@ -521,14 +587,10 @@ ListLoop:
 		line := i + 1
 		if igt.ifg.lineNumber == line+1 && igt.elseg.lineNumber < line {
 			innerIrs := []intermediateRepresentation{}
-			// TODO: one problem is gotos that reach within the control struct
-			// for now, dont allow this rewrite if such a goto exists in the program
-			for _, irr := range irlist {
-				if g, ok := irr.(goTo); ok {
-					if g.lineNumber > igt.elseg.lineNumber && g.lineNumber < line+1 {
-						continue ListLoop
-					}
-				}
+			// gotos that reach within the control struct would have nowhere to go,
+			// dont allow this rewrite if such a goto exists in the program
+			if jumpsInto(irlist, igt.elseg.lineNumber, line) {
+				continue
 			}
 			for _, previr := range irlist[igt.elseg.lineNumber:line] {
 				if _, ok := previr.(goTo); ok {
@@ -541,6 +603,7 @@ ListLoop:
 			}
 			newIR := doWhile{
 				cond: igt.cond,
+				flag: igt.flag,
 				irs:  innerIrs,
 			}
 			return updateList(newIR, irlist, igt.elseg.lineNumber, len(innerIrs)), true
@@ -571,6 +634,10 @@ ListLoop:
 		if !ok {
 			continue
 		}
+		// a goto to the do-while would skip the initialisation
+		if jumpsInto(irlist, line, line+1) {
+			continue
+		}
 		for _, irr := range dw.irs {
 			if _, ok := irr.(goTo); ok {
 				continue ListLoop
@@ -597,6 +664,7 @@ ListLoop:
 			loopvar: a.assignee,
 			init:    a.value,
 			cond:    dw.cond,
+			flag:    dw.flag,
 			irs:     dw.irs[:len(dw.irs)-1],
 		}
 		return updateList(newIR, irlist, line, 1), true
@@ -631,24 +699,99 @@ func updateGotos(irlist []intermediateRepresentation, line, n int) []intermediat
 		if ok {
 			newList[i] = ifGotos{
 				cond:  ifgotos.cond,
+				flag:  ifgotos.flag,
 				ifg:   updateGoto(ifgotos.ifg, line, n),
 				elseg: updateGoto(ifgotos.elseg, line, n),
 			}
 			continue
 		}
+		ij, ok := ir.(indirectJump)
+		if ok {
+			shifts := append([]shift{}, ij.shifts...)
+			newList[i] = indirectJump{
+				target: ij.target,
+				shifts: append(shifts, shift{line, n}),
+			}
+			continue
+		}
 		ifst, ok := ir.(ifstatement)
 		if ok {
 			newList[i] = ifstatement{
 				cond: ifst.cond,
+				flag: ifst.flag,
 				irs:  updateGotos(ifst.irs, line, n),
 			}
 			continue
 		}
+		dw, ok := ir.(doWhile)
+		if ok {
+			newList[i] = doWhile{
+				cond: dw.cond,
+				flag: dw.flag,
+				irs:  updateGotos(dw.irs, line, n),
+			}
+			continue
+		}
+		fl, ok := ir.(forLoop)
+		if ok {
+			newList[i] = forLoop{
+				loopvar: fl.loopvar,
+				init:    fl.init,
+				cond:    fl.cond,
+				flag:    fl.flag,
+				irs:     updateGotos(fl.irs, line, n),
+			}
+			continue
+		}
 		newList[i] = ir
 	}
 	return newList
 }
 
+// jumpsInto returns true if a goto outside of the lines from up to and
+// including to jumps to a line after from. Those lines are about to be
+// folded into a control structure at from, leaving no label to jump to
+func jumpsInto(irlist []intermediateRepresentation, from, to int) bool {
+	for line, ir := range irlist {
+		if line >= from && line <= to {
+			continue
+		}
+		if jumpsBetween(ir, from, to) {
+			return true
+		}
+	}
+	return false
+}
+
+// jumpsBetween returns true if ir, or anything nested in it,
+// jumps to a line after from up to and including to
+func jumpsBetween(ir intermediateRepresentation, from, to int) bool {
+	into := func(g goTo) bool {
+		return g.lineNumber > from && g.lineNumber <= to
+	}
+	nested := func(irs []intermediateRepresentation) bool {
+		for _, ir := range irs {
+			if jumpsBetween(ir, from, to) {
+				return true
+			}
+		}
+		return false
+	}
+	switch t := ir.(type) {
+	case goTo:
+		return into(t)
+	case ifGotos:
+		return into(t.ifg) || into(t.elseg)
+	case ifstatement:
+		return nested(t.irs)
+	case doWhile:
+		return nested(t.irs)
+	case forLoop:
+		return nested(t.irs)
+	}
+	return false
+}
+
 func updateGoto(g goTo, line, n int) goTo {
 	if g.lineNumber <= line {
 		return g
@@ -1,19 +1,21 @@
-package main
+package day19
 
 import (
 	"reflect"
 	"testing"
+
+	"github.com/deosjr/adventofcode2018/elfcode"
 )
 
 func TestTranslate(t *testing.T) {
 	for i, tt := range []struct {
-		instr instruction
+		instr elfcode.Instruction
 		line  int
 		ptr   int
 		want  intermediateRepresentation
 	}{
 		{
-			instr: instruction{addr{}, 1, 1, 1},
+			instr: elfcode.Instruction{Op: elfcode.Addr, A: 1, B: 1, C: 1},
 			line:  1,
 			ptr:   4,
 			want: assignment{
@@ -22,20 +24,56 @@ func TestTranslate(t *testing.T) {
 			},
 		},
 		{
-			instr: instruction{seti{}, 1, 1, 4},
+			instr: elfcode.Instruction{Op: elfcode.Seti, A: 1, B: 1, C: 4},
 			line:  15,
 			ptr:   4,
 			want:  goTo{lineNumber: 2},
 		},
 		{
-			instr: instruction{addi{}, 4, 1, 4},
+			instr: elfcode.Instruction{Op: elfcode.Addi, A: 4, B: 1, C: 4},
 			line:  15,
 			ptr:   4,
 			want:  goTo{lineNumber: 17},
 		},
+		{
+			instr: elfcode.Instruction{Op: elfcode.Mulr, A: 4, B: 4, C: 4},
+			line:  16,
+			ptr:   4,
+			want:  goTo{lineNumber: 257},
+		},
+		{
+			instr: elfcode.Instruction{Op: elfcode.Bori, A: 4, B: 3, C: 4},
+			line:  4,
+			ptr:   4,
+			want:  goTo{lineNumber: 8},
+		},
+		{
+			instr: elfcode.Instruction{Op: elfcode.Setr, A: 2, B: 0, C: 4},
+			line:  3,
+			ptr:   4,
+			want:  indirectJump{target: r{2}},
+		},
+		{
+			instr: elfcode.Instruction{Op: elfcode.Muli, A: 1, B: 2, C: 4},
+			line:  3,
+			ptr:   4,
+			want:  indirectJump{target: op{"*", r{1}, v{2}}},
+		},
+		{
+			instr: elfcode.Instruction{Op: elfcode.Addr, A: 4, B: 3, C: 4},
+			line:  5,
+			ptr:   4,
+			want:  indirectJump{target: op{"+", v{5}, r{3}}},
+		},
+		{
+			instr: elfcode.Instruction{Op: elfcode.Addr, A: 1, B: 2, C: 4},
+			line:  3,
+			ptr:   4,
+			want:  indirectJump{target: op{"+", r{1}, r{2}}},
+		},
 	} {
-		got := tt.instr.opcode.translate(tt.instr, tt.line, tt.ptr)
-		if got != tt.want {
+		got := translate(tt.instr, tt.line, tt.ptr)
+		if !reflect.DeepEqual(got, tt.want) {
 			t.Errorf("%d): got %v want %v", i, got, tt.want)
 		}
 	}
@@ -50,11 +88,25 @@ func TestIfElsePattern(t *testing.T) {
 		{
 			list: []intermediateRepresentation{
 				comparison{cond: op{operator: "==", x: r{}, y: r{}}, r: r{}},
-				assignmentOp{operator: "+", assignee: r{}, value: r{}},
+				indirectJump{target: op{operator: "+", x: v{1}, y: r{}}},
+				goTo{lineNumber: 10},
+			},
+			want: []intermediateRepresentation{
+				ifGotos{cond: op{operator: "==", x: r{}, y: r{}}, flag: &r{}, ifg: goTo{1}, elseg: goTo{8}},
+			},
+			ptr: 0,
+		},
+		{
+			// r1 is not the register the comparison wrote
+			list: []intermediateRepresentation{
+				comparison{cond: op{operator: "==", x: r{}, y: r{}}, r: r{}},
+				indirectJump{target: op{operator: "+", x: v{1}, y: r{1}}},
 				goTo{lineNumber: 10},
 			},
 			want: []intermediateRepresentation{
-				ifGotos{cond: op{operator: "==", x: r{}, y: r{}}, ifg: goTo{1}, elseg: goTo{8}},
+				comparison{cond: op{operator: "==", x: r{}, y: r{}}, r: r{}},
+				indirectJump{target: op{operator: "+", x: v{1}, y: r{1}}},
+				goTo{lineNumber: 10},
 			},
 			ptr: 0,
 		},
@@ -64,7 +116,7 @@ func TestIfElsePattern(t *testing.T) {
 				goTo{lineNumber: 12},
 				assignmentOp{operator: "+", assignee: r{}, value: r{}},
 				comparison{cond: op{operator: "==", x: r{}, y: r{}}, r: r{}},
-				assignmentOp{operator: "+", assignee: r{}, value: r{}},
+				indirectJump{target: op{operator: "+", x: r{}, y: v{4}}},
 				goTo{lineNumber: 1},
 				goTo{lineNumber: 15},
 			},
@@ -72,7 +124,7 @@ func TestIfElsePattern(t *testing.T) {
 				goTo{lineNumber: 2},
 				goTo{lineNumber: 10},
 				assignmentOp{operator: "+", assignee: r{}, value: r{}},
-				ifGotos{cond: op{operator: "==", x: r{}, y: r{}}, ifg: goTo{4}, elseg: goTo{1}},
+				ifGotos{cond: op{operator: "==", x: r{}, y: r{}}, flag: &r{}, ifg: goTo{4}, elseg: goTo{1}},
 				goTo{lineNumber: 13},
 			},
 			ptr: 0,
@@ -168,23 +220,21 @@ func TestDoWhile(t *testing.T) {
 			},
 			ptr: 0,
 		},
-		// TODO: fix this test?
 		{
 			list: []intermediateRepresentation{
 				goTo{2}, // goto to inside dowhile is a problem!!
 				assignmentOp{operator: "+", assignee: r{1}, value: v{5}},
 				assignmentOp{operator: "+", assignee: r{2}, value: v{6}},
-				ifGotos{cond: op{operator: "==", x: r{}, y: r{}}, ifg: goTo{4}, elseg: goTo{1}},
+				ifGotos{cond: op{operator: "==", x: r{}, y: r{}}, flag: &r{}, ifg: goTo{4}, elseg: goTo{1}},
 				goTo{10},
 			},
+			// so the rewrite is not allowed
 			want: []intermediateRepresentation{
-				doWhile{
-					cond: op{operator: "==", x: r{}, y: r{}},
-					irs: []intermediateRepresentation{
-						assignmentOp{operator: "+", assignee: r{1}, value: v{5}},
-						assignmentOp{operator: "+", assignee: r{2}, value: v{6}},
-					},
-				},
+				goTo{2},
+				assignmentOp{operator: "+", assignee: r{1}, value: v{5}},
+				assignmentOp{operator: "+", assignee: r{2}, value: v{6}},
+				ifGotos{cond: op{operator: "==", x: r{}, y: r{}}, flag: &r{}, ifg: goTo{4}, elseg: goTo{1}},
+				goTo{10},
 			},
 			ptr: 0,
 		},
@@ -232,37 +282,3 @@ func TestForLoop(t *testing.T) {
 		}
 	}
 }
-
-func TestRewritePointer(t *testing.T) {
-	for i, tt := range []struct {
-		list []intermediateRepresentation
-		want []intermediateRepresentation
-		ptr  int
-	}{
-		{
-			list: []intermediateRepresentation{
-				goTo{lineNumber: 1},
-				goTo{lineNumber: 9},
-				assignmentOp{operator: "+", assignee: r{2}, value: v{6}},
-				assignmentOp{operator: "+", assignee: r{4}, value: r{3}},
-				goTo{lineNumber: 1},
-				goTo{lineNumber: 9},
-			},
-			want: []intermediateRepresentation{
-				goTo{lineNumber: 1},
-				goTo{lineNumber: 10},
-				assignmentOp{operator: "+", assignee: r{2}, value: v{6}},
-				comparison{cond: op{operator: "==", x: r{3}, y: v{1}}, r: r{3}},
-				assignmentOp{operator: "+", assignee: r{4}, value: r{3}},
-				goTo{lineNumber: 1},
-				goTo{lineNumber: 10},
-			},
-			ptr: 4,
-		},
-	} {
-		got := rewritePointer(tt.list, tt.ptr)
-		if !reflect.DeepEqual(got, tt.want) {
-			t.Errorf("%d): got\n%s --want \n%s", i, print(got), print(tt.want))
-		}
-	}
-}
@@ -0,0 +1,255 @@
+package day19
+
+import (
+	"fmt"
+	"go/format"
+	"strings"
+
+	"github.com/deosjr/adventofcode2018/elfcode"
+)
+
+// Compile lifts an elfcode program to intermediate representation and lowers
+// it to a Go source file in package pkg, with a single function fn
+// that takes and returns the register file
+func Compile(input, pkg, fn string) (string, error) {
+	p, err := parse(input)
+	if err != nil {
+		return "", err
+	}
+	src, err := generate(lift(p.IPRegister, p.Instructions), p.IPRegister, len(p.Instructions), 6, fn)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("package %s\n\n%s", pkg, src), nil
+}
+
+// lift translates the program to IR and runs all rewrite passes over it
+func lift(insPtr int, program []elfcode.Instruction) []intermediateRepresentation {
+	irlist := make([]intermediateRepresentation, len(program))
+	for i, p := range program {
+		irlist[i] = translate(p, i, insPtr)
+	}
+	irlist = rewriteOperators(irlist, insPtr)
+	return compile(irlist, insPtr)
+}
+
+// codegen lowers a list of IR to Go. Gotos that are left over after
+// the rewrites jump to labels, which are only emitted on the top level
+// so a goto never jumps into a block. The pointer register is only
+// written when returning, with the line the program halted after
+type codegen struct {
+	sb        strings.Builder
+	insPtr    int
+	registers int
+	// number of lines now and in the original program
+	lines    int
+	original int
+	labels   map[int]bool
+}
+
+// generate returns the gofmt'ed source of a function called name,
+// lowering irlist that was lifted from a program of original lines
+func generate(irlist []intermediateRepresentation, insPtr, original, registers int, name string) (string, error) {
+	c := &codegen{
+		insPtr:    insPtr,
+		registers: registers,
+		lines:     len(irlist),
+		original:  original,
+		labels:    map[int]bool{},
+	}
+	c.findLabels(irlist)
+
+	var regs, args []string
+	for i := 0; i < registers; i++ {
+		if i != insPtr {
+			regs = append(regs, r{i}.print())
+			args = append(args, fmt.Sprintf("r[%d]", i))
+		}
+	}
+	c.printf("func %s(r [%d]int) [%d]int {\n", name, registers, registers)
+	c.printf("%s := %s\n", strings.Join(regs, ", "), strings.Join(args, ", "))
+	for i, ir := range irlist {
+		if c.labels[i] {
+			c.printf("L%d:\n", i)
+		}
+		if err := c.statement(ir); err != nil {
+			return "", fmt.Errorf("line %d: %w", i, err)
+		}
+	}
+	c.ret(fmt.Sprint(c.original - 1))
+	c.printf("}\n")
+
+	src, err := format.Source([]byte(c.sb.String()))
+	if err != nil {
+		return "", err
+	}
+	return string(src), nil
+}
+
+func (c *codegen) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&c.sb, format, args...)
+}
+
+// findLabels marks every line that is the target of a goto
+func (c *codegen) findLabels(irlist []intermediateRepresentation) {
+	for _, ir := range irlist {
+		switch t := ir.(type) {
+		case indirectJump:
+			for _, line := range c.indirectTargets(t) {
+				if line != folded {
+					c.labels[line] = true
+				}
+			}
+		case goTo:
+			c.labels[t.lineNumber] = true
+		case ifGotos:
+			c.labels[t.ifg.lineNumber] = true
+			c.labels[t.elseg.lineNumber] = true
+		case ifstatement:
+			c.findLabels(t.irs)
+		case doWhile:
+			c.findLabels(t.irs)
+		case forLoop:
+			c.findLabels(t.irs)
+		}
+	}
+}
+
+// ret returns the registers, with ip in the pointer register
+func (c *codegen) ret(ip string) {
+	regs := make([]string, c.registers)
+	for i := range regs {
+		regs[i] = r{i}.print()
+	}
+	regs[c.insPtr] = ip
+	c.printf("return [%d]int{%s}\n", c.registers, strings.Join(regs, ", "))
+}
+
+// folded is the target of a line that is now part of a control structure
+const folded = -1
+
+// indirectTargets maps every line of the original program to
+// the line it is at now, for an indirect jump to switch over
+func (c *codegen) indirectTargets(ij indirectJump) []int {
+	targets := make([]int, c.original)
+	for line := range targets {
+		if l, ok := ij.relocate(line); ok {
+			targets[line] = l
+		} else {
+			targets[line] = folded
+		}
+	}
+	return targets
+}
+
+// jump to a line; jumping outside of the program halts it.
+// Gotos past the end have moved with every line folded away
+func (c *codegen) jump(g goTo) {
+	switch {
+	case g.lineNumber < 0:
+		c.ret(fmt.Sprint(g.lineNumber - 1))
+	case g.lineNumber >= c.lines:
+		c.ret(fmt.Sprint(g.lineNumber + c.original - c.lines - 1))
+	default:
+		c.printf("goto L%d\n", g.lineNumber)
+	}
+}
+
+// condition returns the Go expression to branch on for cond,
+// writing its result to flag first if there is one
+func (c *codegen) condition(cond op, flag *r) string {
+	if flag == nil {
+		return cond.print()
+	}
+	c.comparison(comparison{cond: cond, r: *flag})
+	return flag.print() + " == 1"
+}
+
+func (c *codegen) comparison(t comparison) {
+	c.printf("if %s {\n%s = 1\n} else {\n%s = 0\n}\n", t.cond.print(), t.r.print(), t.r.print())
+}
+
+// assign refuses writes to the pointer register: translate turns
+// those into jumps, anything else would not jump when lowered
+func (c *codegen) assign(x r) error {
+	if x.i == c.insPtr {
+		return fmt.Errorf("cannot generate Go for a write to the pointer register r%d", x.i)
+	}
+	return nil
+}
+
+func (c *codegen) statements(irlist []intermediateRepresentation) error {
+	for _, ir := range irlist {
+		if err := c.statement(ir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *codegen) statement(ir intermediateRepresentation) error {
+	switch t := ir.(type) {
+	case assignment:
+		if err := c.assign(t.assignee); err != nil {
+			return err
+		}
+		c.printf("%s = %s\n", t.assignee.print(), t.value.print())
+	case assignmentOp:
+		if err := c.assign(t.assignee); err != nil {
+			return err
+		}
+		c.printf("%s %s= %s\n", t.assignee.print(), t.operator, t.value.print())
+	case comparison:
+		if err := c.assign(t.r); err != nil {
+			return err
+		}
+		c.comparison(t)
+	case goTo:
+		c.jump(t)
+	case end:
+		c.ret(fmt.Sprint(t.lineNumber))
+	case indirectJump:
+		c.printf("switch %s + 1 {\n", t.target.print())
+		for line, target := range c.indirectTargets(t) {
+			c.printf("case %d:\n", line)
+			if target == folded {
+				c.printf("panic(%q)\n", fmt.Sprintf("indirect jump to line %d, which is folded into a control structure", line))
+				continue
+			}
+			c.jump(goTo{target})
+		}
+		c.printf("default:\n")
+		c.ret(t.target.print())
+		c.printf("}\n")
+	case ifGotos:
+		c.printf("if %s {\n", c.condition(t.cond, t.flag))
+		c.jump(t.ifg)
+		c.printf("} else {\n")
+		c.jump(t.elseg)
+		c.printf("}\n")
+	case ifstatement:
+		c.printf("if %s {\n", c.condition(t.cond, t.flag))
+		if err := c.statements(t.irs); err != nil {
+			return err
+		}
+		c.printf("}\n")
+	case doWhile:
+		c.printf("for {\n")
+		if err := c.statements(t.irs); err != nil {
+			return err
+		}
+		c.printf("if %s {\nbreak\n}\n}\n", c.condition(t.cond, t.flag))
+	case forLoop:
+		// the condition is checked after the increment, as in the do-while it came from
+		loopvar := t.loopvar.print()
+		c.printf("for %s = %s; ; {\n", loopvar, t.init.print())
+		if err := c.statements(t.irs); err != nil {
+			return err
+		}
+		c.printf("%s++\n", loopvar)
+		c.printf("if %s {\nbreak\n}\n}\n", c.condition(t.cond, t.flag))
+	default:
+		return fmt.Errorf("cannot generate Go for %T", ir)
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package day19
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deosjr/adventofcode2018/elfcode"
+)
+
+func TestCompile(t *testing.T) {
+	for i, tt := range []struct {
+		file  string
+		input string
+		want  []string
+		// r0 are values of register 0 for which the program halts soon
+		r0 []int
+	}{
+		{
+			file: "day19.input",
+			want: []string{"goto L3", "L1:\n\tfor r1 = 1; ; {", "r2 *= 19"},
+			r0:   []int{0},
+		},
+		{
+			file:  "indirect",
+			input: indirect,
+			want:  []string{"switch r4 + 1 {", "case 1:\n\t\tgoto L1", "default:\n\t\treturn"},
+			r0:    []int{0, 1},
+		},
+		{
+			file:  "relative",
+			input: relative,
+			want:  []string{"switch 1 + r1 + 1 {", "case 4:\n\t\tgoto L4"},
+			r0:    []int{0},
+		},
+		{
+			// line 2 jumps relative to a comparison, line 4 to r1
+			file: "mixed",
+			input: `#ip 5
+				seti 3 0 1
+				eqri 1 3 2
+				addr 5 2 5
+				seti 9 0 3
+				addi 1 2 5
+				seti 7 0 4`,
+			want: []string{"switch 2 + r2 + 1 {", "switch r1 + 2 + 1 {"},
+			r0:   []int{0},
+		},
+		{
+			// line 4 is the body of an if, but line 7 jumps to it as well
+			file: "into",
+			input: `#ip 5
+				seti 7 0 2
+				eqri 0 1 3
+				addr 5 3 5
+				seti 4 0 5
+				addi 2 1 2
+				eqri 2 8 3
+				addr 5 3 5
+				seti 3 0 5
+				seti 9 0 1`,
+			want: []string{"L2:\n\tr2 += 1", "} else {\n\t\tgoto L2"},
+			r0:   []int{0, 1},
+		},
+		{
+			file: "../21/day21.input",
+			want: []string{"L3:\n\tr2 = r3 | 65536", "if r1 == 1 {\n\t\treturn [6]int{r0, r1, r2, r3, r4, 30}"},
+			// the first value the halting check compares against
+			r0: []int{212115},
+		},
+	} {
+		input := strings.Replace(tt.input, "\t", "", -1)
+		if input == "" {
+			b, err := ioutil.ReadFile(tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			input = string(b)
+		}
+		src, err := Compile(input, "elfcode", "run")
+		if err != nil {
+			t.Fatalf("%d): %v", i, err)
+		}
+		// the generated code should typecheck, which includes gotos and labels
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, tt.file+".go", src, 0)
+		if err != nil {
+			t.Fatalf("%d): %v\n%s", i, err, src)
+		}
+		if _, err := (&types.Config{}).Check("elfcode", fset, []*ast.File{f}, nil); err != nil {
+			t.Errorf("%d): %v\n%s", i, err, src)
+		}
+		for _, w := range tt.want {
+			if !strings.Contains(src, w) {
+				t.Errorf("%d): missing %q in\n%s", i, w, src)
+			}
+		}
+
+		// and it should end with the same registers as the program itself
+		if testing.Short() {
+			continue
+		}
+		p, err := parse(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := runGenerated(t, src, tt.r0)
+		for j, r0 := range tt.r0 {
+			vm := elfcode.New(p, elfcode.Registers)
+			vm.Registers[0] = r0
+			for steps := 0; !vm.Halted(); steps++ {
+				if steps == 100000000 {
+					t.Fatalf("%d): r0=%d does not halt", i, r0)
+				}
+				vm.Step()
+			}
+			for r, want := range vm.Registers {
+				if got[j][r] != want {
+					t.Errorf("%d): r0=%d got r%d=%d want %d", i, r0, r, got[j][r], want)
+				}
+			}
+		}
+	}
+}
+
+func TestGenerateRefusesPointerWrites(t *testing.T) {
+	for i, ir := range []intermediateRepresentation{
+		assignment{assignee: r{5}, value: v{3}},
+		assignmentOp{operator: "+", assignee: r{5}, value: r{2}},
+		comparison{cond: op{operator: "==", x: r{1}, y: v{3}}, r: r{5}},
+	} {
+		irlist := []intermediateRepresentation{ir, assignment{assignee: r{4}, value: v{7}}}
+		if _, err := generate(irlist, 5, 2, 6, "run"); err == nil {
+			t.Errorf("%d): expected error", i)
+		}
+	}
+}
+
+// runGenerated builds the source from Compile with a main that calls run
+// once for every value of r0, returning the register files it printed
+func runGenerated(t *testing.T, src string, r0 []int) [][6]int {
+	t.Helper()
+	gobin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("no go command to build the generated code with")
+	}
+	main := `import "fmt"
+
+func main() {
+	for _, r0 := range []int{` + strings.ReplaceAll(strings.Trim(fmt.Sprint(r0), "[]"), " ", ", ") + `} {
+		r := run([6]int{r0})
+		fmt.Println(r[0], r[1], r[2], r[3], r[4], r[5])
+	}
+}
+`
+	dir := t.TempDir()
+	file := filepath.Join(dir, "main.go")
+	src = strings.Replace(src, "package elfcode\n", "package main\n\n"+main, 1)
+	if err := os.WriteFile(file, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	bin := filepath.Join(dir, "main")
+	if out, err := exec.Command(gobin, "build", "-o", bin, file).CombinedOutput(); err != nil {
+		t.Fatalf("%v\n%s\n%s", err, out, src)
+	}
+	// a wrong translation might never halt
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, bin).Output()
+	if ctx.Err() != nil {
+		t.Fatalf("generated code did not halt within 10s\n%s", src)
+	}
+	if err != nil {
+		t.Fatalf("%v\n%s", err, src)
+	}
+	var files [][6]int
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		var r [6]int
+		if _, err := fmt.Sscanf(line, "%d %d %d %d %d %d", &r[0], &r[1], &r[2], &r[3], &r[4], &r[5]); err != nil {
+			t.Fatalf("%v: %q", err, line)
+		}
+		files = append(files, r)
+	}
+	return files
+}
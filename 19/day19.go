@@ -1,214 +1,56 @@
-package main
+package day19
 
 import (
-	"fmt"
-	"io/ioutil"
-	"strings"
-)
-
-type register [6]int
-
-type opcode interface {
-	do(register, int, int, int) register
-	// used for bonus
-	translate(instr instruction, lineNumber int, instrPtr int) intermediateRepresentation
-}
-
-type addr struct{}
-
-func (addr) do(r register, a, b, c int) register {
-	r[c] = r[a] + r[b]
-	return r
-}
-
-type addi struct{}
-
-func (addi) do(r register, a, b, c int) register {
-	r[c] = r[a] + b
-	return r
-}
-
-type mulr struct{}
-
-func (mulr) do(r register, a, b, c int) register {
-	r[c] = r[a] * r[b]
-	return r
-}
-
-type muli struct{}
-
-func (muli) do(r register, a, b, c int) register {
-	r[c] = r[a] * b
-	return r
-}
-
-type banr struct{}
-
-func (banr) do(r register, a, b, c int) register {
-	r[c] = r[a] & r[b]
-	return r
-}
-
-type bani struct{}
-
-func (bani) do(r register, a, b, c int) register {
-	r[c] = r[a] & b
-	return r
-}
-
-type borr struct{}
-
-func (borr) do(r register, a, b, c int) register {
-	r[c] = r[a] | r[b]
-	return r
-}
-
-type bori struct{}
-
-func (bori) do(r register, a, b, c int) register {
-	r[c] = r[a] | b
-	return r
-}
-
-type setr struct{}
-
-func (setr) do(r register, a, b, c int) register {
-	r[c] = r[a]
-	return r
-}
-
-type seti struct{}
+	"errors"
 
-func (seti) do(r register, a, b, c int) register {
-	r[c] = a
-	return r
-}
-
-type gtir struct{}
-
-func (gtir) do(r register, a, b, c int) register {
-	if a > r[b] {
-		r[c] = 1
-		return r
-	}
-	r[c] = 0
-	return r
-}
-
-type gtri struct{}
-
-func (gtri) do(r register, a, b, c int) register {
-	if r[a] > b {
-		r[c] = 1
-		return r
-	}
-	r[c] = 0
-	return r
-}
-
-type gtrr struct{}
-
-func (gtrr) do(r register, a, b, c int) register {
-	if r[a] > r[b] {
-		r[c] = 1
-		return r
-	}
-	r[c] = 0
-	return r
-}
-
-type eqir struct{}
-
-func (eqir) do(r register, a, b, c int) register {
-	if a == r[b] {
-		r[c] = 1
-		return r
-	}
-	r[c] = 0
-	return r
-}
-
-type eqri struct{}
+	"github.com/deosjr/adventofcode2018/elfcode"
+)
 
-func (eqri) do(r register, a, b, c int) register {
-	if r[a] == b {
-		r[c] = 1
-		return r
+func parse(input string) (elfcode.Program, error) {
+	p, err := elfcode.Parse(input)
+	if err != nil {
+		return elfcode.Program{}, err
 	}
-	r[c] = 0
-	return r
-}
-
-type eqrr struct{}
-
-func (eqrr) do(r register, a, b, c int) register {
-	if r[a] == r[b] {
-		r[c] = 1
-		return r
+	if p.IPRegister < 0 {
+		return elfcode.Program{}, errors.New("program does not bind the instruction pointer: missing #ip")
 	}
-	r[c] = 0
-	return r
+	return p, nil
 }
 
-type instruction struct {
-	opcode  opcode
-	a, b, c int
+// the instruction pointer itself is not a register: when the program
+// halts, the bound register keeps the value of the last instruction
+func part1(p elfcode.Program) int {
+	vm := elfcode.New(p, elfcode.Registers)
+	vm.Run()
+	return vm.Registers[0]
 }
 
-func (i instruction) execute(r register) register {
-	return i.opcode.do(r, i.a, i.b, i.c)
-}
-
-var opcodes = map[string]opcode{"addr": addr{}, "addi": addi{}, "mulr": mulr{}, "muli": muli{},
-	"banr": banr{}, "bani": bani{}, "borr": borr{}, "bori": bori{}, "setr": setr{}, "seti": seti{},
-	"gtir": gtir{}, "gtri": gtri{}, "gtrr": gtrr{}, "eqir": eqir{}, "eqri": eqri{}, "eqrr": eqrr{}}
-
-func parse(input string) (int, []instruction) {
-	split := strings.Split(input, "\n")
-	var insPtr int
-	fmt.Sscanf(split[0], "#ip %d", &insPtr)
-	program := make([]instruction, len(split[1:]))
-	for i, s := range split[1:] {
-		var opStr string
-		var a, b, c int
-		fmt.Sscanf(s, "%s %d %d %d", &opStr, &a, &b, &c)
-		program[i] = instruction{opcodes[opStr], a, b, c}
-	}
-	return insPtr, program
-}
-
-func part1(insPtr int, program []instruction) int {
-	var r register
-	for r[insPtr] >= 0 && r[insPtr] < len(program) {
-		ins := program[r[insPtr]]
-		r = ins.execute(r)
-		r[insPtr] = r[insPtr] + 1
+// running part2 like part 1 will take an insane amount of time:
+// it spends most of its time in a loop summing the divisors of a number.
+// Instead we lift the program to IR and let accelerate find that loop
+// and replace it by a closed form (see bonus for a code version)
+func part2(p elfcode.Program) (int, error) {
+	var reg register
+	reg[0] = 1
+	reg, err := accelerate(lift(p.IPRegister, p.Instructions), p.IPRegister, reg)
+	if err != nil {
+		return 0, err
 	}
-	return r[0]
+	return reg[0], nil
 }
 
-// running part2 like part 1 will take an insane amount of time
-// it spends most of its time in a loop
-// this is the loop part optimised and written in Go
-// (see bonus for a code version)
-func part2(r2 int) int {
-	r0 := 0
-	for r1 := 1; r1 <= r2; r1++ {
-		if r2%r1 == 0 {
-			r0 += r1
-		}
+func Part1(input string) (interface{}, error) {
+	p, err := parse(input)
+	if err != nil {
+		return nil, err
 	}
-	return r0
+	return part1(p), nil
 }
 
-func main() {
-	input, err := ioutil.ReadFile("day19.input")
+func Part2(input string) (interface{}, error) {
+	p, err := parse(input)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-	insPtr, program := parse(string(input))
-	reg0 := part1(insPtr, program)
-	fmt.Printf("Part 1: %d\n", reg0)
-	fmt.Printf("Part 2: %d\n", part2(10551376))
-	fmt.Printf("Bonus: %s\n", bonus(insPtr, program))
+	return part2(p)
 }
@@ -0,0 +1,25 @@
+package day19
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPart1(t *testing.T) {
+	input := `#ip 0
+			seti 5 0 1
+			seti 6 0 2
+			addi 0 1 0
+			addr 1 2 3
+			setr 1 0 0
+			seti 8 0 4
+			seti 9 0 5`
+	p, err := parse(strings.Replace(input, "\t", "", -1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := part1(p)
+	if got != 6 {
+		t.Errorf("got %d want %d", got, 6)
+	}
+}
@@ -0,0 +1,181 @@
+package day19
+
+import "fmt"
+
+// register is the register file the IR runs on
+type register [6]int
+
+// next tells the interpreter where to continue after executing an IR node
+type next struct {
+	jump bool
+	// line to jump to; jumping outside of the program halts it
+	line int
+}
+
+// continue with the statement after this one
+var fallthru = next{}
+
+// halt the program
+var halt = next{jump: true, line: -1}
+
+// run interprets a list of IR from the first line until it jumps outside of the list.
+// Like the elfcode VM, the pointer register holds the line number during
+// each statement and writing to it jumps to the line after the one written.
+// Only the unstructured IR straight out of translate relies on this
+func run(irlist []intermediateRepresentation, insPtr int, reg register) register {
+	line := 0
+	for line >= 0 && line < len(irlist) {
+		var n next
+		reg[insPtr] = line
+		reg, n = irlist[line].execute(reg)
+		if n.jump {
+			line = n.line
+			continue
+		}
+		if reg[insPtr] != line {
+			line = reg[insPtr] + 1
+			continue
+		}
+		line++
+	}
+	return reg
+}
+
+// executeList runs a nested block, stopping early if a statement jumps
+func executeList(irlist []intermediateRepresentation, reg register) (register, next) {
+	for _, ir := range irlist {
+		var n next
+		reg, n = ir.execute(reg)
+		if n.jump {
+			return reg, n
+		}
+	}
+	return reg, fallthru
+}
+
+func (r r) value(reg register) int {
+	return reg[r.i]
+}
+
+func (v v) value(register) int {
+	return v.i
+}
+
+func (op op) value(reg register) int {
+	return apply(op.operator, op.x.value(reg), op.y.value(reg))
+}
+
+func apply(operator string, x, y int) int {
+	switch operator {
+	case "+":
+		return x + y
+	case "*":
+		return x * y
+	case "&":
+		return x & y
+	case "|":
+		return x | y
+	case ">":
+		return boolToInt(x > y)
+	case "==":
+		return boolToInt(x == y)
+	}
+	panic("unknown operator " + operator)
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (a assignment) execute(reg register) (register, next) {
+	reg[a.assignee.i] = a.value.value(reg)
+	return reg, fallthru
+}
+
+func (a assignmentOp) execute(reg register) (register, next) {
+	reg[a.assignee.i] = apply(a.operator, reg[a.assignee.i], a.value.value(reg))
+	return reg, fallthru
+}
+
+func (c comparison) execute(reg register) (register, next) {
+	reg[c.r.i] = c.cond.value(reg)
+	return reg, fallthru
+}
+
+func (g goTo) execute(reg register) (register, next) {
+	return reg, next{jump: true, line: g.lineNumber}
+}
+
+func (ij indirectJump) execute(reg register) (register, next) {
+	target := ij.target.value(reg) + 1
+	line, ok := ij.relocate(target)
+	if !ok {
+		panic(fmt.Sprintf("indirect jump to line %d, which is folded into a control structure", target))
+	}
+	return reg, next{jump: true, line: line}
+}
+
+func (e end) execute(reg register) (register, next) {
+	return reg, halt
+}
+
+// test evaluates a condition, writing the result to flag if there is one
+func test(cond op, flag *r, reg register) (register, bool) {
+	c := cond.value(reg)
+	if flag != nil {
+		reg[flag.i] = c
+	}
+	return reg, c == 1
+}
+
+func (i ifGotos) execute(reg register) (register, next) {
+	reg, ok := test(i.cond, i.flag, reg)
+	if ok {
+		return i.ifg.execute(reg)
+	}
+	return i.elseg.execute(reg)
+}
+
+func (i ifstatement) execute(reg register) (register, next) {
+	reg, ok := test(i.cond, i.flag, reg)
+	if ok {
+		return executeList(i.irs, reg)
+	}
+	return reg, fallthru
+}
+
+// do { irs } while !(cond)
+func (dw doWhile) execute(reg register) (register, next) {
+	for {
+		var n next
+		reg, n = executeList(dw.irs, reg)
+		if n.jump {
+			return reg, n
+		}
+		var ok bool
+		if reg, ok = test(dw.cond, dw.flag, reg); ok {
+			return reg, fallthru
+		}
+	}
+}
+
+// same as the do-while it was rewritten from:
+// the condition is checked after the increment
+func (fl forLoop) execute(reg register) (register, next) {
+	reg[fl.loopvar.i] = fl.init.value(reg)
+	for {
+		var n next
+		reg, n = executeList(fl.irs, reg)
+		if n.jump {
+			return reg, n
+		}
+		reg[fl.loopvar.i]++
+		var ok bool
+		if reg, ok = test(fl.cond, fl.flag, reg); ok {
+			return reg, fallthru
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package day19
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/deosjr/adventofcode2018/elfcode"
+)
+
+// TestRewritesPreserveSemantics runs the program after every rewrite pass
+// and compares the outcome against the elfcode VM
+func TestRewritesPreserveSemantics(t *testing.T) {
+	all := []int{0, 1, 2, 3, 4, 5}
+	for _, tt := range []struct {
+		file  string
+		input string
+		r0    int
+	}{
+		{file: "day19.input", r0: 0},
+		// halts on the first value checked against r0
+		{file: "../21/day21.input", r0: 212115},
+		{file: "example", input: example},
+		{file: "indirect", input: indirect},
+		{file: "relative", input: relative},
+	} {
+		input := strings.Replace(tt.input, "\t", "", -1)
+		if input == "" {
+			b, err := ioutil.ReadFile(tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			input = string(b)
+		}
+		p, err := parse(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		vm := elfcode.New(p, 6)
+		vm.Registers[0] = tt.r0
+		vm.Run()
+		var want register
+		copy(want[:], vm.Registers)
+
+		insPtr := p.IPRegister
+		irlist := make([]intermediateRepresentation, len(p.Instructions))
+		for i, ins := range p.Instructions {
+			irlist[i] = translate(ins, i, insPtr)
+		}
+		for _, pass := range []struct {
+			name    string
+			rewrite func([]intermediateRepresentation, int) []intermediateRepresentation
+			// registers that should match the VM, which the pointer register never does
+			compare []int
+		}{
+			{"translate", func(irlist []intermediateRepresentation, _ int) []intermediateRepresentation { return irlist }, all},
+			{"rewriteOperators", rewriteOperators, all},
+			{"compile", compile, all},
+		} {
+			irlist = pass.rewrite(irlist, insPtr)
+			got := run(irlist, insPtr, register{tt.r0})
+			for _, i := range pass.compare {
+				if i != insPtr && got[i] != want[i] {
+					t.Errorf("%s after %s: got %v want %v", tt.file, pass.name, got, want)
+					break
+				}
+			}
+		}
+	}
+}
+
+// the example from the puzzle, jumping with setr
+var example = `#ip 0
+			seti 5 0 1
+			seti 6 0 2
+			addi 0 1 0
+			addr 1 2 3
+			setr 1 0 0
+			seti 8 0 4
+			seti 9 0 5`
+
+// a loop using an indirect jump back to line 1
+var indirect = `#ip 5
+			seti 0 0 1
+			addi 1 3 1
+			gtri 1 10 3
+			muli 3 4 4
+			setr 4 0 5
+			seti 7 0 2`
+
+// a jump relative to the pointer by a register that is not a boolean
+var relative = `#ip 5
+			seti 2 0 1
+			addr 5 1 5
+			seti 7 0 2
+			seti 8 0 3
+			seti 9 0 4`
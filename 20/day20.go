@@ -1,8 +1,9 @@
-package main
+package day20
 
 import (
-	"fmt"
-	"io/ioutil"
+	"strings"
+
+	"github.com/deosjr/adventofcode2018/scan"
 )
 
 type coord struct {
@@ -117,12 +118,49 @@ func newRoom(rooms map[coord]*room, currentRoom *room, newPos coord) *room {
 	return r
 }
 
-func main() {
-	input, err := ioutil.ReadFile("day20.input")
+// parse checks the input is a single regex of the form ^[NESW|()]*$
+func parse(input string) (string, error) {
+	r := scan.NewReader(input)
+	if !r.Scan() {
+		return "", r.Errorf("^[NESW|()]*$", "empty input")
+	}
+	regex := r.Text()
+	if !strings.HasPrefix(regex, "^") || !strings.HasSuffix(regex, "$") {
+		return "", r.Errorf("^[NESW|()]*$", "regex should start with ^ and end with $")
+	}
+	depth := 0
+	for i, c := range regex[1 : len(regex)-1] {
+		switch c {
+		case 'N', 'E', 'S', 'W', '|':
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return "", r.Errorf("^[NESW|()]*$", "unbalanced ) at column %d", i+2)
+			}
+		default:
+			return "", r.Errorf("^[NESW|()]*$", "unexpected character %q at column %d", c, i+2)
+		}
+	}
+	if depth != 0 {
+		return "", r.Errorf("^[NESW|()]*$", "unbalanced (")
+	}
+	return regex, nil
+}
+
+func Part1(input string) (interface{}, error) {
+	regex, err := parse(input)
+	if err != nil {
+		return nil, err
+	}
+	return part1(explore(regex)), nil
+}
+
+func Part2(input string) (interface{}, error) {
+	regex, err := parse(input)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-	rooms := explore(string(input))
-	fmt.Printf("Part 1: %d\n", part1(rooms))
-	fmt.Printf("Part 2: %d\n", part2(rooms))
+	return part2(explore(regex)), nil
 }
@@ -0,0 +1,21 @@
+package day20
+
+import "testing"
+
+func TestPart1(t *testing.T) {
+	for i, tt := range []struct {
+		input string
+		want  int
+	}{
+		{input: "^WNE$", want: 3},
+		{input: "^ENWWW(NEEE|SSE(EE|N))$", want: 10},
+		{input: "^ENNWSWW(NEWS|)SSSEEN(WNSE|)EE(SWEN|)NNN$", want: 18},
+		{input: "^ESSWWN(E|NNENN(EESS(WNSE|)SSS|WWWSSSSE(SW|NNNE)))$", want: 23},
+		{input: "^WSSEESWWWNW(S|NENNEEEENN(ESSSSW(NWSW|SSEN)|WSWWN(E|WWS(E|SS))))$", want: 31},
+	} {
+		got := part1(explore(tt.input))
+		if got != tt.want {
+			t.Errorf("%d): got %d want %d", i, got, tt.want)
+		}
+	}
+}
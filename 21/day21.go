@@ -1,118 +1,251 @@
-package main
+package day21
 
-import "fmt"
+import (
+	"errors"
 
-// this is the input code after compiling elfcode to go
-// using the (half-finished) compiler from day 19 bonus
+	"github.com/deosjr/adventofcode2018/cycle"
+	"github.com/deosjr/adventofcode2018/elfcode"
+)
+
+// this is the input code as printed by aoc disasm -day 21
 
 /*
-0: r3 = 123
-1: do {
-	r3 &= 456
-} while !(r3 == 72)
-2: r3 = 0
-3: r2 = r3 | 65536
-4: r3 = 832312
-5: r1 = r2 & 255
-6: r3 += r1
-7: r3 &= 16777215
-8: r3 *= 65899
-9: r3 &= 16777215
-10: if 256 > r2 {
-goto 19
-}
-11: r1 = 0
-12: r4 = r1 + 1
-13: r4 *= 256
-14: if r4 > r2 {
-goto 17
-}
-15: r1 += 1
-16: goto 12
-17: r2 = r1
-18: goto 5
-19: if r3 == r0 {
-	goto 20
-} else {
-	goto 3
-}
+#ip 5
+	r3 = 123               // 0: seti 123 0 3
+L1:
+	r3 = r3 & 456          // 1: bani 3 456 3
+	if r3 == 72 goto L5    // 2: eqri 3 72 3, 3: addr 3 5 5
+	goto L1                // 4: seti 0 0 5
+L5:
+	r3 = 0                 // 5: seti 0 5 3
+L6:
+	r2 = r3 | 65536        // 6: bori 3 65536 2
+	r3 = 832312            // 7: seti 832312 1 3
+L8:
+	r1 = r2 & 255          // 8: bani 2 255 1
+	r3 = r3 + r1           // 9: addr 3 1 3
+	r3 = r3 & 16777215     // 10: bani 3 16777215 3
+	r3 = r3 * 65899        // 11: muli 3 65899 3
+	r3 = r3 & 16777215     // 12: bani 3 16777215 3
+	if 256 > r2 goto L16   // 13: gtir 256 2 1, 14: addr 1 5 5
+	goto L17               // 15: addi 5 1 5
+L16:
+	goto L28               // 16: seti 27 7 5
+L17:
+	r1 = 0                 // 17: seti 0 2 1
+L18:
+	r4 = r1 + 1            // 18: addi 1 1 4
+	r4 = r4 * 256          // 19: muli 4 256 4
+	if r4 > r2 goto L23    // 20: gtrr 4 2 4, 21: addr 4 5 5
+	goto L24               // 22: addi 5 1 5
+L23:
+	goto L26               // 23: seti 25 1 5
+L24:
+	r1 = r1 + 1            // 24: addi 1 1 1
+	goto L18               // 25: seti 17 0 5
+L26:
+	r2 = r1                // 26: setr 1 7 2
+	goto L8                // 27: seti 7 2 5
+L28:
+	if r3 == r0 goto halt  // 28: eqrr 3 0 1, 29: addr 1 5 5
+	goto L6                // 30: seti 5 5 5
 */
 
-// rewritten is the above as a function rewritten by hand in multiple steps
-// line 0 and line 1 are discarded as they are just the bitwise-and check
 // Basically the program generates a looping sequence of numbers for r3
-// part 1 is get the first, part 2 is get the last number of the sequence
-// this function is not actually used in the answer, see inner()
-func rewritten(r0 int) {
-	var r1, r2, r3, r4 int
-	for {
-		r2 = r3 | 65536
-		r3 = 832312
-		for {
-			r1 = r2 & 255
-			r3 += r1
-			r3 &= 16777215
-			r3 *= 65899
-			r3 &= 16777215
-			if 256 > r2 {
-				break
-			}
-			r1 = 0
-		L12:
-			r4 = r1 + 1
-			r4 *= 256
-			if !(r4 > r2) {
-				r1 += 1
-				goto L12
-			}
-			r2 = r1
+// and halts when r0 is equal to it. Part 1 is the first number of the
+// sequence, part 2 the last one before it repeats.
+// Lines 17 to 25 divide r2 by 256 by counting up, which is where the
+// program spends nearly all of its time
+
+// haltCheck finds the only instruction reading register 0: eqrr X 0 Y or eqrr 0 X Y.
+// It returns its line and the register X compared against
+func haltCheck(p elfcode.Program) (int, int, error) {
+	for i, ins := range p.Instructions {
+		if ins.Op.Name != elfcode.Eqrr.Name {
+			continue
 		}
-		if r3 == r0 {
-			return
+		if ins.B == 0 {
+			return i, ins.A, nil
+		}
+		if ins.A == 0 {
+			return i, ins.B, nil
 		}
 	}
+	return 0, 0, errors.New("no eqrr instruction comparing against register 0")
+}
+
+// division is a loop that sets q to n / d, rounded down, by counting
+// up until (q+1)*d > n. Only the registers and constants differ per input:
+//
+//	seti 0 _ q
+//	addi q 1 t      <- start
+//	muli t d t
+//	gtrr t n t
+//	addr t ip ip
+//	addi ip 1 ip
+//	seti exit-1 _ ip
+//	addi q 1 q
+//	seti start-1 _ ip
+type division struct {
+	start, exit int
+	q, t, n, d  int
 }
 
-// the inner loop of func rewritten
-func inner(r0, r1, r2, r3, r4 int) (int, int, int, int, int) {
-	for {
-		r1 = r2 & 255
-		r3 += r1
-		r3 &= 16777215
-		r3 *= 65899
-		r3 &= 16777215
-		if 256 > r2 {
-			return r0, r1, r2, r3, r4
+// findDivision returns the first division loop in the program
+func findDivision(p elfcode.Program) (division, bool) {
+	ip := p.IPRegister
+	is := func(ins elfcode.Instruction, op elfcode.Op, a, b, c int) bool {
+		return ins.Op.Name == op.Name && ins.A == a && ins.B == b && ins.C == c
+	}
+	for i := 0; i+8 < len(p.Instructions); i++ {
+		ins := p.Instructions[i : i+9]
+		q, t, n, d := ins[0].C, ins[1].C, ins[3].B, ins[2].B
+		if ip < 0 || q == t || q == ip || t == ip || n == ip || n == q || n == t || d <= 0 {
+			continue
+		}
+		if ins[0].Op.Name != elfcode.Seti.Name || ins[0].A != 0 ||
+			!is(ins[1], elfcode.Addi, q, 1, t) ||
+			!is(ins[2], elfcode.Muli, t, d, t) ||
+			!is(ins[3], elfcode.Gtrr, t, n, t) ||
+			!(is(ins[4], elfcode.Addr, t, ip, ip) || is(ins[4], elfcode.Addr, ip, t, ip)) ||
+			!is(ins[5], elfcode.Addi, ip, 1, ip) ||
+			ins[6].Op.Name != elfcode.Seti.Name || ins[6].C != ip ||
+			!is(ins[7], elfcode.Addi, q, 1, q) ||
+			ins[8].Op.Name != elfcode.Seti.Name || ins[8].A != i || ins[8].C != ip {
+			continue
 		}
-		r2 = r2 / 256
+		return division{start: i + 1, exit: ins[6].A + 1, q: q, t: t, n: n, d: d}, true
 	}
+	return division{}, false
 }
 
-func part1() int {
-	var r0, r1, r2, r3, r4 int
-	r2 = r3 | 65536
-	r3 = 832312
-	r0, r1, r2, r3, r4 = inner(r0, r1, r2, r3, r4)
-	return r3
+// skip moves the VM from the start of the loop to where it exits:
+// q becomes the smallest value not below q itself with (q+1)*d > n
+func (div division) skip(vm *elfcode.VM) {
+	r := vm.Registers
+	n, d := r[div.n], div.d
+	q := n / d
+	if n%d != 0 && n < 0 {
+		q--
+	}
+	if q > r[div.q] {
+		r[div.q] = q
+	}
+	r[div.t] = 1
+	r[vm.Program.IPRegister] = div.exit - 1
+	vm.IP = div.exit
+}
+
+// state is the register file when the program reaches the halt check
+type state [elfcode.Registers]int
+
+// checker runs the program from one halt check to the next,
+// skipping over division loops
+type checker struct {
+	p         elfcode.Program
+	line, reg int
+	div       division
+	// start is the state when the halt check is first reached
+	start state
+	err   error
 }
 
-func part2() int {
-	answers := map[int]struct{}{}
-	lastAnswer := 0
-	var r0, r1, r2, r3, r4 int
-	for {
-		r2 = r3 | 65536
-		r3 = 832312
-		r0, r1, r2, r3, r4 = inner(r0, r1, r2, r3, r4)
-		if _, ok := answers[r3]; ok {
-			return lastAnswer
+func newChecker(p elfcode.Program) (*checker, error) {
+	line, reg, err := haltCheck(p)
+	if err != nil {
+		return nil, err
+	}
+	c := &checker{p: p, line: line, reg: reg}
+	div, ok := findDivision(p)
+	if !ok {
+		div.start = -1
+	}
+	c.div = div
+	vm := elfcode.New(p, elfcode.Registers)
+	if err := c.run(vm); err != nil {
+		return nil, err
+	}
+	copy(c.start[:], vm.Registers)
+	return c, nil
+}
+
+// run steps the VM until it reaches the halt check
+func (c *checker) run(vm *elfcode.VM) error {
+	for vm.IP != c.line {
+		if vm.Halted() {
+			return errors.New("program halted before reaching the halt check")
+		}
+		if vm.IP == c.div.start {
+			c.div.skip(vm)
+			continue
+		}
+		vm.Step()
+	}
+	return nil
+}
+
+// next returns the state at the halt check after s, assuming the check
+// fails. If the program halts, the error is kept and s is returned
+// so any search for a cycle ends
+func (c *checker) next(s state) state {
+	vm := elfcode.New(c.p, elfcode.Registers)
+	copy(vm.Registers, s[:])
+	vm.IP = c.line
+	vm.Step()
+	if err := c.run(vm); err != nil {
+		c.err = err
+		return s
+	}
+	copy(s[:], vm.Registers)
+	return s
+}
+
+// part1 is the first value compared against register 0:
+// it is the one that halts the program soonest
+func part1(p elfcode.Program) (int, error) {
+	c, err := newChecker(p)
+	if err != nil {
+		return 0, err
+	}
+	return c.start[c.reg], nil
+}
+
+// part2 is the last new value compared against register 0 before the
+// state at the check repeats: it is the one that halts the program latest.
+// Brent finds where the states repeat without remembering them, but values
+// can repeat before the state does, so those are kept track of on a second walk
+func part2(p elfcode.Program) (int, error) {
+	c, err := newChecker(p)
+	if err != nil {
+		return 0, err
+	}
+	cyc, _ := cycle.Brent(c.start, c.next)
+	if c.err != nil {
+		return 0, c.err
+	}
+	last, seen := 0, map[int]bool{}
+	s := c.start
+	for i := 0; i < cyc.Start+cyc.Length; i++ {
+		if v := s[c.reg]; !seen[v] {
+			last, seen[v] = v, true
 		}
-		answers[r3] = struct{}{}
-		lastAnswer = r3
+		s = c.next(s)
 	}
+	return last, c.err
 }
 
-func main() {
-	fmt.Printf("Part 1: %d\n", part1())
-	fmt.Printf("Part 2: %d\n", part2())
+func Part1(input string) (interface{}, error) {
+	p, err := elfcode.Parse(input)
+	if err != nil {
+		return nil, err
+	}
+	return part1(p)
+}
+
+func Part2(input string) (interface{}, error) {
+	p, err := elfcode.Parse(input)
+	if err != nil {
+		return nil, err
+	}
+	return part2(p)
 }
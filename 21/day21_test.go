@@ -0,0 +1,112 @@
+package day21
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/deosjr/adventofcode2018/elfcode"
+)
+
+// divides r2 by 7 into r1, then checks r1 against r0
+const divide = `#ip 5
+seti 0 0 1
+addi 1 1 4
+muli 4 7 4
+gtrr 4 2 4
+addr 4 5 5
+addi 5 1 5
+seti 8 0 5
+addi 1 1 1
+seti 0 0 5
+eqrr 1 0 3`
+
+func TestFindDivision(t *testing.T) {
+	p, err := elfcode.Parse(divide)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok := findDivision(p)
+	want := division{start: 1, exit: 9, q: 1, t: 4, n: 2, d: 7}
+	if !ok || got != want {
+		t.Fatalf("got %+v, %t want %+v", got, ok, want)
+	}
+	// the loop should end the same when stepped through or skipped over
+	for _, n := range []int{-15, -14, -1, 0, 6, 7, 50} {
+		slow := elfcode.New(p, 6)
+		slow.Registers[2] = n
+		for slow.IP != want.exit {
+			slow.Step()
+		}
+		fast := elfcode.New(p, 6)
+		fast.Registers[2] = n
+		fast.Step()
+		got.skip(fast)
+		if fast.IP != slow.IP || !reflect.DeepEqual(fast.Registers, slow.Registers) {
+			t.Errorf("%d: got ip %d %v want ip %d %v", n, fast.IP, fast.Registers, slow.IP, slow.Registers)
+		}
+	}
+}
+
+func TestNoDivision(t *testing.T) {
+	// multiplies by 8 instead of checking q+1
+	p, err := elfcode.Parse(strings.Replace(divide, "addi 1 1 4", "addi 1 2 4", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d, ok := findDivision(p); ok {
+		t.Errorf("found %+v", d)
+	}
+}
+
+func TestValues(t *testing.T) {
+	p, err := elfcode.Parse(divide)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := part1(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// r2 is 0, so r1 is 0 as well
+	if got != 0 {
+		t.Errorf("got %d want 0", got)
+	}
+	if _, err := part1(elfcode.Program{IPRegister: 5, Instructions: p.Instructions[:9]}); err == nil {
+		t.Error("expected error without a halt check")
+	}
+}
+
+// r4 counts 1, 2, 3, 4 over and over and the check compares r4*r4&5 + 1,
+// which is 2, 5, 2, 1, against r0. The values repeat before the registers do
+const counter = `#ip 5
+seti 0 0 4
+addi 4 1 4
+gtri 4 4 1
+addr 1 5 5
+seti 5 0 5
+seti 1 0 4
+mulr 4 4 3
+bani 3 5 3
+addi 3 1 3
+eqrr 3 0 1
+addr 1 5 5
+seti 0 0 5`
+
+func TestCarriedState(t *testing.T) {
+	p, err := elfcode.Parse(counter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := part1(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last, err := part2(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != 2 || last != 1 {
+		t.Errorf("got %d, %d want 2, 1", first, last)
+	}
+}
@@ -1,10 +1,11 @@
-package main
+package day22
 
 import (
 	"container/heap"
-	"fmt"
-	"io/ioutil"
 	"math"
+
+	"github.com/deosjr/adventofcode2018/grid"
+	"github.com/deosjr/adventofcode2018/scan"
 )
 
 type region int
@@ -15,30 +16,26 @@ const (
 	narrow
 )
 
-type coord struct {
-	x, y int
-}
-
 // assumption from input: tx < ty
 func part1(depth, tx, ty int) int {
 	sum := 0
-	m := map[coord]int{}
+	m := grid.NewDense[int](tx+1, ty+1)
 	for y := 0; y <= ty; y++ {
 		for x := 0; x <= tx; x++ {
-			c := coord{x, y}
+			c := grid.Coord{X: x, Y: y}
 			g := geologicIndex(m, x, y)
 			if y == ty && x == tx {
 				g = 0
 			}
 			e := erosionLevel(g, depth)
 			sum += int(regionType(e))
-			m[c] = e
+			m.Set(c, e)
 		}
 	}
 	return sum
 }
 
-func geologicIndex(m map[coord]int, x, y int) int {
+func geologicIndex(m *grid.Dense[int], x, y int) int {
 	// also catches the first case, {0,0}
 	if y == 0 {
 		return x * 16807
@@ -47,7 +44,7 @@ func geologicIndex(m map[coord]int, x, y int) int {
 		return y * 48271
 	}
 	// guaranteed to exist due to traversal order
-	return m[coord{x - 1, y}] * m[coord{x, y - 1}]
+	return m.At(grid.Coord{X: x - 1, Y: y}) * m.At(grid.Coord{X: x, Y: y - 1})
 }
 
 func erosionLevel(geo, depth int) int {
@@ -59,9 +56,9 @@ func regionType(erosion int) region {
 }
 
 func part2(depth, tx, ty int) int {
-	erosion := map[coord]int{}
-	start := posTool{0, 0, torch}
-	goal := posTool{tx, ty, torch}
+	erosion := grid.NewSparse[int]()
+	start := posTool{grid.Coord{}, torch}
+	goal := posTool{grid.Coord{X: tx, Y: ty}, torch}
 
 	return findRoute(start, goal, erosion, depth)
 }
@@ -75,63 +72,62 @@ const (
 )
 
 type posTool struct {
-	x, y int
+	pos  grid.Coord
 	tool tool
 }
 
-func geologicIndexPart2(m map[coord]int, depth int, pos, target coord) int {
-	if pos.y == target.y && pos.x == target.x {
+func geologicIndexPart2(m *grid.Sparse[int], depth int, pos, target grid.Coord) int {
+	if pos == target {
 		return 0
 	}
 	// also catches the first case, {0,0}
-	if pos.y == 0 {
-		return pos.x * 16807
+	if pos.Y == 0 {
+		return pos.X * 16807
 	}
-	if pos.x == 0 {
-		return pos.y * 48271
+	if pos.X == 0 {
+		return pos.Y * 48271
 	}
 
-	left := coord{pos.x - 1, pos.y}
-	leftErosion, ok := m[left]
+	left := pos.Add(grid.Left)
+	leftErosion, ok := m.Get(left)
 	if !ok {
 		leftErosion = erosionLevelPart2(m, depth, left, target)
 	}
-	up := coord{pos.x, pos.y - 1}
-	upErosion, ok := m[up]
+	up := pos.Add(grid.Up)
+	upErosion, ok := m.Get(up)
 	if !ok {
 		upErosion = erosionLevelPart2(m, depth, up, target)
 	}
 	return leftErosion * upErosion
 }
 
-func erosionLevelPart2(m map[coord]int, depth int, pos, target coord) int {
-	if v, ok := m[pos]; ok {
+func erosionLevelPart2(m *grid.Sparse[int], depth int, pos, target grid.Coord) int {
+	if v, ok := m.Get(pos); ok {
 		return v
 	}
 	geo := geologicIndexPart2(m, depth, pos, target)
 	erosion := (geo + depth) % 20183
-	m[pos] = erosion
+	m.Set(pos, erosion)
 	return erosion
 }
 
-func regionTypePart2(m map[coord]int, depth int, pos, target coord) region {
+func regionTypePart2(m *grid.Sparse[int], depth int, pos, target grid.Coord) region {
 	e := erosionLevelPart2(m, depth, pos, target)
 	return regionType(e)
 }
 
-func neighbours(pt, goal posTool, erosion map[coord]int, depth int) []posTool {
-	pos := coord{pt.x, pt.y}
-	ncoord := []coord{{pos.x + 1, pos.y}, {pos.x, pos.y + 1}}
-	if pos.x != 0 {
-		ncoord = append(ncoord, coord{pos.x - 1, pos.y})
-	}
-	if pos.y != 0 {
-		ncoord = append(ncoord, coord{pos.x, pos.y - 1})
+func neighbours(pt, goal posTool, erosion *grid.Sparse[int], depth int) []posTool {
+	ncoord := []grid.Coord{}
+	for _, n := range pt.pos.Neighbours4() {
+		// no negative coordinates
+		if n.X < 0 || n.Y < 0 {
+			continue
+		}
+		ncoord = append(ncoord, n)
 	}
 
-	self := posTool{pt.x, pt.y, 0}
-	goalPos := coord{goal.x, goal.y}
-	r := regionTypePart2(erosion, depth, pos, goalPos)
+	self := posTool{pt.pos, 0}
+	r := regionTypePart2(erosion, depth, pt.pos, goal.pos)
 	switch r {
 	case rocky:
 		if pt.tool == torch {
@@ -155,12 +151,12 @@ func neighbours(pt, goal posTool, erosion map[coord]int, depth int) []posTool {
 
 	n := []posTool{self}
 	for _, nc := range ncoord {
-		nr := regionTypePart2(erosion, depth, nc, goalPos)
+		nr := regionTypePart2(erosion, depth, nc, goal.pos)
 		neededTool, ok := travel(r, nr, pt.tool)
 		if !ok {
 			continue
 		}
-		n = append(n, posTool{nc.x, nc.y, neededTool})
+		n = append(n, posTool{nc, neededTool})
 	}
 	return n
 }
@@ -198,7 +194,7 @@ func travel(from, to region, t tool) (tool, bool) {
 	return t, false
 }
 
-func findRoute(start, goal posTool, erosion map[coord]int, depth int) int {
+func findRoute(start, goal posTool, erosion *grid.Sparse[int], depth int) int {
 	openSet := map[posTool]bool{
 		start: true,
 	}
@@ -259,7 +255,7 @@ func findRoute(start, goal posTool, erosion map[coord]int, depth int) int {
 
 func g(p, q posTool) float64 {
 	var min float64
-	if !(p.x == q.x && p.y == q.y) {
+	if p.pos != q.pos {
 		min += 1.0
 	}
 	if p.tool != q.tool {
@@ -269,19 +265,13 @@ func g(p, q posTool) float64 {
 }
 
 func h(p, q posTool) float64 {
-	m := manhattan(p, q)
+	m := float64(p.pos.Manhattan(q.pos))
 	if p.tool != q.tool {
 		m += 7
 	}
 	return m
 }
 
-func manhattan(p, q posTool) float64 {
-	dx := float64(q.x - p.x)
-	dy := float64(q.y - p.y)
-	return math.Abs(dx) + math.Abs(dy)
-}
-
 func reconstructPath(m map[posTool]posTool, current posTool) []posTool {
 	path := []posTool{current}
 	for {
@@ -331,13 +321,30 @@ func (pq *priorityQueue) Pop() interface{} {
 	return item
 }
 
-func main() {
-	input, err := ioutil.ReadFile("day22.input")
+func parse(input string) (int, int, int, error) {
+	var depth, tx, ty int
+	r := scan.NewReader(input)
+	if err := r.Next("depth: %d", &depth); err != nil {
+		return 0, 0, 0, err
+	}
+	if err := r.Next("target: %d,%d", &tx, &ty); err != nil {
+		return 0, 0, 0, err
+	}
+	return depth, tx, ty, nil
+}
+
+func Part1(input string) (interface{}, error) {
+	depth, tx, ty, err := parse(input)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-	var depth, tx, ty int
-	fmt.Sscanf(string(input), "depth: %d\ntarget: %d,%d", &depth, &tx, &ty)
-	fmt.Printf("Part 1: %d\n", part1(depth, tx, ty))
-	fmt.Printf("Part 2: %d\n", part2(depth, tx, ty))
+	return part1(depth, tx, ty), nil
+}
+
+func Part2(input string) (interface{}, error) {
+	depth, tx, ty, err := parse(input)
+	if err != nil {
+		return nil, err
+	}
+	return part2(depth, tx, ty), nil
 }
@@ -0,0 +1,16 @@
+package day22
+
+import "testing"
+
+func TestParts(t *testing.T) {
+	depth, tx, ty, err := parse("depth: 510\ntarget: 10,10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := part1(depth, tx, ty); got != 114 {
+		t.Errorf("part1: got %d want %d", got, 114)
+	}
+	if got := part2(depth, tx, ty); got != 45 {
+		t.Errorf("part2: got %d want %d", got, 45)
+	}
+}
@@ -1,10 +1,9 @@
-package main
+package day23
 
 import (
-	"fmt"
-	"io/ioutil"
 	"math"
-	"strings"
+
+	"github.com/deosjr/adventofcode2018/scan"
 )
 
 type nanobot struct {
@@ -27,15 +26,17 @@ func (n nanobot) inRange(p coord) bool {
 	return manhattan(n.pos, p) <= n.r
 }
 
-func parse(input string) []nanobot {
-	split := strings.Split(input, "\n")
-	nanobots := make([]nanobot, len(split))
-	for i, s := range split {
-		var x, y, z, r int
-		fmt.Sscanf(s, "pos=<%d,%d,%d>, r=%d", &x, &y, &z, &r)
-		nanobots[i] = nanobot{coord{x, y, z}, r}
+func parse(input string) ([]nanobot, error) {
+	nanobots := []nanobot{}
+	r := scan.NewReader(input)
+	for r.Scan() {
+		var x, y, z, rad int
+		if err := r.Scanf("pos=<%d,%d,%d>, r=%d", &x, &y, &z, &rad); err != nil {
+			return nil, err
+		}
+		nanobots = append(nanobots, nanobot{coord{x, y, z}, rad})
 	}
-	return nanobots
+	return nanobots, nil
 }
 
 func part1(nanobots []nanobot) int {
@@ -103,12 +104,18 @@ func part2(nanobots []nanobot) int {
 	return manhattan(coord{0, 0, 0}, best[0])
 }
 
-func main() {
-	input, err := ioutil.ReadFile("day23.input")
+func Part1(input string) (interface{}, error) {
+	nanobots, err := parse(input)
+	if err != nil {
+		return nil, err
+	}
+	return part1(nanobots), nil
+}
+
+func Part2(input string) (interface{}, error) {
+	nanobots, err := parse(input)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-	nanobots := parse(string(input))
-	fmt.Printf("Part 1: %d\n", part1(nanobots))
-	fmt.Printf("Part 2: %d\n", part2(nanobots))
+	return part2(nanobots), nil
 }
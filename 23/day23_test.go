@@ -0,0 +1,43 @@
+package day23
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPart1(t *testing.T) {
+	input := `pos=<0,0,0>, r=4
+			pos=<1,0,0>, r=1
+			pos=<4,0,0>, r=3
+			pos=<0,2,0>, r=1
+			pos=<0,5,0>, r=3
+			pos=<0,0,3>, r=1
+			pos=<1,1,1>, r=1
+			pos=<1,1,2>, r=1
+			pos=<1,3,1>, r=1`
+	nanobots, err := parse(strings.Replace(input, "\t", "", -1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := part1(nanobots)
+	if got != 7 {
+		t.Errorf("got %d want %d", got, 7)
+	}
+}
+
+func TestPart2(t *testing.T) {
+	input := `pos=<10,12,12>, r=2
+			pos=<12,14,12>, r=2
+			pos=<16,12,12>, r=4
+			pos=<14,14,14>, r=6
+			pos=<50,50,50>, r=200
+			pos=<10,10,10>, r=5`
+	nanobots, err := parse(strings.Replace(input, "\t", "", -1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := part2(nanobots)
+	if got != 36 {
+		t.Errorf("got %d want %d", got, 36)
+	}
+}
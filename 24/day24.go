@@ -1,10 +1,10 @@
-package main
+package day24
 
 import (
-	"fmt"
-	"io/ioutil"
 	"sort"
 	"strings"
+
+	"github.com/deosjr/adventofcode2018/scan"
 )
 
 type group struct {
@@ -43,22 +43,44 @@ func (g *group) takeDamage(d int) {
 	g.units -= d / g.hitPoints
 }
 
-func parse(input string) []*group {
-	toplevelsplit := strings.Split(input, "\n\n")
-	immuneStr, infectionStr := toplevelsplit[0], toplevelsplit[1]
-	return append(parseGroups(immuneStr, true), parseGroups(infectionStr, false)...)
+const groupFormat = "%d units each with %d hit points (...) with an attack that does %d %s damage at initiative %d"
+
+func parse(input string) ([]*group, error) {
+	r := scan.NewReader(input)
+	if err := r.Next("Immune System:"); err != nil {
+		return nil, err
+	}
+	immune, err := parseGroups(r, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.Next("Infection:"); err != nil {
+		return nil, err
+	}
+	infection, err := parseGroups(r, false)
+	if err != nil {
+		return nil, err
+	}
+	return append(immune, infection...), nil
 }
 
-func parseGroups(str string, immuneSystem bool) []*group {
-	split := strings.Split(str, "\n")[1:]
-	groups := make([]*group, len(split))
-	for i, s := range split {
+// parseGroups reads groups until a blank line or the end of input
+func parseGroups(r *scan.Reader, immuneSystem bool) ([]*group, error) {
+	var groups []*group
+	for r.Scan() && r.Text() != "" {
 		var units, hitPoints, attackDamage, initiative int
 		var attackType string
-		withAn := strings.Split(s, "with an")
-		points := strings.Split(withAn[0], "points")
-		fmt.Sscanf(points[0], "%d units each with %d hit", &units, &hitPoints)
-		fmt.Sscanf(withAn[1], " attack that does %d %s damage at initiative %d", &attackDamage, &attackType, &initiative)
+		withAn := strings.Split(r.Text(), " with an ")
+		if len(withAn) != 2 {
+			return nil, r.Errorf(groupFormat, "missing attack")
+		}
+		if err := scan.Sscanf(withAn[1], "attack that does %d %s damage at initiative %d", &attackDamage, &attackType, &initiative); err != nil {
+			return nil, r.Error(groupFormat, err)
+		}
+		points := strings.SplitN(withAn[0], " points", 2)
+		if err := scan.Sscanf(points[0], "%d units each with %d hit", &units, &hitPoints); err != nil {
+			return nil, r.Error(groupFormat, err)
+		}
 		g := &group{
 			units:        units,
 			hitPoints:    hitPoints,
@@ -69,20 +91,25 @@ func parseGroups(str string, immuneSystem bool) []*group {
 		}
 
 		// optional: immunities & weaknesses
-		if points[1] != " " {
-			for _, iwstr := range strings.Split(points[1][2:len(points[1])-2], "; ") {
-				if strings.HasPrefix(iwstr, "immune to ") {
+		if len(points) == 2 && points[1] != "" {
+			iw := points[1]
+			if !strings.HasPrefix(iw, " (") || !strings.HasSuffix(iw, ")") {
+				return nil, r.Errorf(groupFormat, "malformed immunities/weaknesses %q", iw)
+			}
+			for _, iwstr := range strings.Split(iw[2:len(iw)-1], "; ") {
+				switch {
+				case strings.HasPrefix(iwstr, "immune to "):
 					g.immunities = strings.Split(iwstr[10:], ", ")
-					continue
-				}
-				if strings.HasPrefix(iwstr, "weak to ") {
+				case strings.HasPrefix(iwstr, "weak to "):
 					g.weaknesses = strings.Split(iwstr[8:], ", ")
+				default:
+					return nil, r.Errorf(groupFormat, "expected immune to or weak to, got %q", iwstr)
 				}
 			}
 		}
-		groups[i] = g
+		groups = append(groups, g)
 	}
-	return groups
+	return groups, nil
 }
 
 func targetSort(groups []*group) func(i, j int) bool {
@@ -221,18 +248,27 @@ func fight(groups []*group) []*group {
 	return remaining
 }
 
-func part2(input string) int {
+func part2(groups []*group) int {
 	var boost int
-	var groups []*group
+	var remaining []*group
 	for {
-		groups = parse(input)
 		boost++
-		groups = combatWithBoost(groups, boost)
-		if immuneSystemWins(groups) {
+		remaining = combatWithBoost(clone(groups), boost)
+		if immuneSystemWins(remaining) {
 			break
 		}
 	}
-	return sumUnits(groups)
+	return sumUnits(remaining)
+}
+
+// clone copies groups so a combat can be rerun from the start
+func clone(groups []*group) []*group {
+	c := make([]*group, len(groups))
+	for i, g := range groups {
+		gc := *g
+		c[i] = &gc
+	}
+	return c
 }
 
 func combatWithBoost(groups []*group, boost int) []*group {
@@ -256,12 +292,18 @@ func immuneSystemWins(groups []*group) bool {
 	return true
 }
 
-func main() {
-	input, err := ioutil.ReadFile("day24.input")
+func Part1(input string) (interface{}, error) {
+	groups, err := parse(input)
+	if err != nil {
+		return nil, err
+	}
+	return part1(groups), nil
+}
+
+func Part2(input string) (interface{}, error) {
+	groups, err := parse(input)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-	groups := parse(string(input))
-	fmt.Printf("Part 1: %d\n", part1(groups))
-	fmt.Printf("Part 2: %d\n", part2(string(input)))
+	return part2(groups), nil
 }
@@ -0,0 +1,57 @@
+package day24
+
+import (
+	"strings"
+	"testing"
+)
+
+var testInput = `Immune System:
+17 units each with 5390 hit points (weak to radiation, bludgeoning) with an attack that does 4507 fire damage at initiative 2
+989 units each with 1274 hit points (immune to fire; weak to bludgeoning, slashing) with an attack that does 25 slashing damage at initiative 3
+
+Infection:
+801 units each with 4706 hit points (weak to radiation) with an attack that does 116 bludgeoning damage at initiative 1
+4485 units each with 2961 hit points (immune to radiation; weak to fire, cold) with an attack that does 12 slashing damage at initiative 4`
+
+func mustParse(t *testing.T) []*group {
+	groups, err := parse(testInput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return groups
+}
+
+func TestPart1(t *testing.T) {
+	got := part1(mustParse(t))
+	if got != 5216 {
+		t.Errorf("got %d want %d", got, 5216)
+	}
+}
+
+func TestCombatWithBoost(t *testing.T) {
+	groups := combatWithBoost(mustParse(t), 1570)
+	if !immuneSystemWins(groups) {
+		t.Errorf("immune system should win with boost 1570")
+	}
+	if got := sumUnits(groups); got != 51 {
+		t.Errorf("got %d want %d", got, 51)
+	}
+}
+
+func TestPart2(t *testing.T) {
+	got := part2(mustParse(t))
+	if got != 51 {
+		t.Errorf("got %d want %d", got, 51)
+	}
+}
+
+func TestParseGroups(t *testing.T) {
+	groups := mustParse(t)
+	if len(groups) != 4 {
+		t.Fatalf("got %d groups want %d", len(groups), 4)
+	}
+	g := groups[1]
+	if strings.Join(g.immunities, ",") != "fire" || strings.Join(g.weaknesses, ",") != "bludgeoning,slashing" {
+		t.Errorf("got immunities %v weaknesses %v", g.immunities, g.weaknesses)
+	}
+}
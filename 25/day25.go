@@ -1,10 +1,9 @@
-package main
+package day25
 
 import (
-	"fmt"
-	"io/ioutil"
 	"math"
-	"strings"
+
+	"github.com/deosjr/adventofcode2018/scan"
 )
 
 type point struct {
@@ -19,19 +18,23 @@ func manhattan(p, q point) int {
 	return int(math.Abs(da) + math.Abs(db) + math.Abs(dc) + math.Abs(dd))
 }
 
-func main() {
-	input, err := ioutil.ReadFile("day25.input")
-	if err != nil {
-		panic(err)
+func parse(input string) ([]point, error) {
+	points := []point{}
+	r := scan.NewReader(input)
+	for r.Scan() {
+		var p point
+		if err := r.Scanf("%d,%d,%d,%d", &p.a, &p.b, &p.c, &p.d); err != nil {
+			return nil, err
+		}
+		points = append(points, p)
 	}
-	split := strings.Split(string(input), "\n")
-	constellations := [][]point{}
+	return points, nil
+}
 
-	for _, s := range split {
-		var a, b, c, d int
-		fmt.Sscanf(s, "%d,%d,%d,%d", &a, &b, &c, &d)
-		p := point{a, b, c, d}
+func part1(points []point) int {
+	constellations := [][]point{}
 
+	for _, p := range points {
 		matched := map[int]struct{}{}
 	Constellations:
 		for i, c := range constellations {
@@ -62,5 +65,13 @@ func main() {
 		}
 		constellations = newCons
 	}
-	fmt.Printf("Part 1: %d\n", len(constellations))
+	return len(constellations)
+}
+
+func Part1(input string) (interface{}, error) {
+	points, err := parse(input)
+	if err != nil {
+		return nil, err
+	}
+	return part1(points), nil
 }
@@ -0,0 +1,74 @@
+package day25
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPart1(t *testing.T) {
+	for i, tt := range []struct {
+		input string
+		want  int
+	}{
+		{
+			input: ` 0,0,0,0
+					 3,0,0,0
+					 0,3,0,0
+					 0,0,3,0
+					 0,0,0,3
+					 0,0,0,6
+					 9,0,0,0
+					12,0,0,0`,
+			want: 2,
+		},
+		{
+			input: `-1,2,2,0
+					0,0,2,-2
+					0,0,0,-2
+					-1,2,0,0
+					-2,-2,-2,2
+					3,0,2,-1
+					-1,3,2,2
+					-1,0,-1,0
+					0,2,1,-2
+					3,0,0,0`,
+			want: 4,
+		},
+		{
+			input: `1,-1,0,1
+					2,0,-1,0
+					3,2,-1,0
+					0,0,3,1
+					0,0,-1,-1
+					2,3,-2,0
+					-2,2,0,0
+					2,-2,0,-1
+					1,-1,0,-1
+					3,2,0,2`,
+			want: 3,
+		},
+		{
+			input: `1,-1,-1,-2
+					-2,-2,0,1
+					0,2,1,3
+					-2,3,-2,1
+					0,2,3,-2
+					-1,-1,1,-2
+					0,-2,-1,0
+					-2,2,3,-1
+					1,2,2,0
+					-1,-2,0,-2`,
+			want: 8,
+		},
+	} {
+		in := strings.Replace(tt.input, "\t", "", -1)
+		points, err := parse(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := part1(points)
+		if got != tt.want {
+			t.Errorf("%d): got %d want %d", i, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package aoc
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// TestAnswers runs every day against its own input
+// and compares with the known answers stored next to it
+func TestAnswers(t *testing.T) {
+	for _, d := range Days() {
+		d := d
+		input, err := ioutil.ReadFile(filepath.Join("..", d.Input()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for part := 1; part <= 2; part++ {
+			solve := d.Part(part)
+			if solve == nil {
+				continue
+			}
+			want, err := ioutil.ReadFile(filepath.Join("..", d.Answer(part)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Run(fmt.Sprintf("day%d/part%d", d.Number, part), func(t *testing.T) {
+				t.Parallel()
+				answer, err := solve(string(input))
+				if err != nil {
+					t.Fatal(err)
+				}
+				got := fmt.Sprint(answer)
+				if got != string(want) {
+					t.Errorf("got %s want %s", got, want)
+				}
+			})
+		}
+	}
+}
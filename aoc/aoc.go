@@ -0,0 +1,110 @@
+// Package aoc collects the solutions to every day of Advent of Code 2018
+// so they can be looked up and run by day number.
+package aoc
+
+import (
+	"fmt"
+
+	day1 "github.com/deosjr/adventofcode2018/01"
+	day2 "github.com/deosjr/adventofcode2018/02"
+	day3 "github.com/deosjr/adventofcode2018/03"
+	day4 "github.com/deosjr/adventofcode2018/04"
+	day5 "github.com/deosjr/adventofcode2018/05"
+	day6 "github.com/deosjr/adventofcode2018/06"
+	day7 "github.com/deosjr/adventofcode2018/07"
+	day8 "github.com/deosjr/adventofcode2018/08"
+	day9 "github.com/deosjr/adventofcode2018/09"
+	day10 "github.com/deosjr/adventofcode2018/10"
+	day11 "github.com/deosjr/adventofcode2018/11"
+	day12 "github.com/deosjr/adventofcode2018/12"
+	day13 "github.com/deosjr/adventofcode2018/13"
+	day14 "github.com/deosjr/adventofcode2018/14"
+	day15 "github.com/deosjr/adventofcode2018/15"
+	day16 "github.com/deosjr/adventofcode2018/16"
+	day17 "github.com/deosjr/adventofcode2018/17"
+	day18 "github.com/deosjr/adventofcode2018/18"
+	day19 "github.com/deosjr/adventofcode2018/19"
+	day20 "github.com/deosjr/adventofcode2018/20"
+	day21 "github.com/deosjr/adventofcode2018/21"
+	day22 "github.com/deosjr/adventofcode2018/22"
+	day23 "github.com/deosjr/adventofcode2018/23"
+	day24 "github.com/deosjr/adventofcode2018/24"
+	day25 "github.com/deosjr/adventofcode2018/25"
+)
+
+// Solver computes the answer to one part of a puzzle from its raw input.
+// Input that cannot be parsed results in a *scan.ParseError.
+type Solver func(input string) (interface{}, error)
+
+// Day holds the solvers for both parts of a day's puzzle.
+// Part2 is nil for day 25, which only has one puzzle.
+type Day struct {
+	Number       int
+	Part1, Part2 Solver
+}
+
+// Part returns the solver for part 1 or 2, or nil if there is none
+func (d Day) Part(part int) Solver {
+	switch part {
+	case 1:
+		return d.Part1
+	case 2:
+		return d.Part2
+	}
+	return nil
+}
+
+// Input returns the path to the puzzle input of this day,
+// relative to the root of the repository
+func (d Day) Input() string {
+	return fmt.Sprintf("%02d/day%d.input", d.Number, d.Number)
+}
+
+// Answer returns the path to the known answer for part 1 or 2 of this day,
+// relative to the root of the repository
+func (d Day) Answer(part int) string {
+	return fmt.Sprintf("%02d/day%d.answer%d", d.Number, d.Number, part)
+}
+
+var days = []Day{
+	{1, day1.Part1, day1.Part2},
+	{2, day2.Part1, day2.Part2},
+	{3, day3.Part1, day3.Part2},
+	{4, day4.Part1, day4.Part2},
+	{5, day5.Part1, day5.Part2},
+	{6, day6.Part1, day6.Part2},
+	{7, day7.Part1, day7.Part2},
+	{8, day8.Part1, day8.Part2},
+	{9, day9.Part1, day9.Part2},
+	{10, day10.Part1, day10.Part2},
+	{11, day11.Part1, day11.Part2},
+	{12, day12.Part1, day12.Part2},
+	{13, day13.Part1, day13.Part2},
+	{14, day14.Part1, day14.Part2},
+	{15, day15.Part1, day15.Part2},
+	{16, day16.Part1, day16.Part2},
+	{17, day17.Part1, day17.Part2},
+	{18, day18.Part1, day18.Part2},
+	{19, day19.Part1, day19.Part2},
+	{20, day20.Part1, day20.Part2},
+	{21, day21.Part1, day21.Part2},
+	{22, day22.Part1, day22.Part2},
+	{23, day23.Part1, day23.Part2},
+	{24, day24.Part1, day24.Part2},
+	{25, day25.Part1, nil},
+}
+
+// Days returns all registered days in order
+func Days() []Day {
+	return days
+}
+
+// Lookup returns the solvers for a given day
+func Lookup(day int) (Day, bool) {
+	for _, d := range days {
+		if d.Number == day {
+			return d, true
+		}
+	}
+	return Day{}, false
+}
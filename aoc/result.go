@@ -0,0 +1,69 @@
+package aoc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Answer types as reported in a Result
+const (
+	IntAnswer    = "int"
+	StringAnswer = "string"
+	GridAnswer   = "grid"
+)
+
+// Result is the outcome of solving one part of a day's puzzle.
+// Answer holds an int, a string or, for grids, a []string of rows.
+type Result struct {
+	Day       int           `json:"day"`
+	Part      int           `json:"part"`
+	Type      string        `json:"type"`
+	Answer    interface{}   `json:"answer"`
+	InputHash string        `json:"input_sha256"`
+	WallTime  time.Duration `json:"wall_time_ns"`
+}
+
+// Solve runs the solver for the given part on input and times it
+func (d Day) Solve(part int, input string) (Result, error) {
+	sum := sha256.Sum256([]byte(input))
+	r := Result{
+		Day:       d.Number,
+		Part:      part,
+		InputHash: hex.EncodeToString(sum[:]),
+	}
+	solve := d.Part(part)
+	start := time.Now()
+	answer, err := solve(input)
+	if err != nil {
+		return r, err
+	}
+	r.WallTime = time.Since(start)
+	r.Type, r.Answer = classify(answer)
+	return r, nil
+}
+
+// classify normalises an answer so it can be reported consistently.
+// Multi-line strings such as the message in day 10 are grids.
+func classify(answer interface{}) (string, interface{}) {
+	switch a := answer.(type) {
+	case int:
+		return IntAnswer, a
+	case string:
+		if strings.Contains(a, "\n") {
+			return GridAnswer, strings.Split(a, "\n")
+		}
+		return StringAnswer, a
+	}
+	return StringAnswer, fmt.Sprint(answer)
+}
+
+// String formats the answer the way the solutions used to print it
+func (r Result) String() string {
+	if r.Type == GridAnswer {
+		return fmt.Sprintf("Part %d:\n%s", r.Part, strings.Join(r.Answer.([]string), "\n"))
+	}
+	return fmt.Sprintf("Part %d: %v", r.Part, r.Answer)
+}
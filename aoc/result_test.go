@@ -0,0 +1,35 @@
+package aoc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	for i, tt := range []struct {
+		answer   interface{}
+		wantType string
+		want     interface{}
+	}{
+		{
+			answer:   42,
+			wantType: IntAnswer,
+			want:     42,
+		},
+		{
+			answer:   "216,12",
+			wantType: StringAnswer,
+			want:     "216,12",
+		},
+		{
+			answer:   "#..#\n.##.",
+			wantType: GridAnswer,
+			want:     []string{"#..#", ".##."},
+		},
+	} {
+		gotType, got := classify(tt.answer)
+		if gotType != tt.wantType || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%d): got %s %v want %s %v", i, gotType, got, tt.wantType, tt.want)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+
+	day16 "github.com/deosjr/adventofcode2018/16"
+	"github.com/deosjr/adventofcode2018/aoc"
+	"github.com/deosjr/adventofcode2018/elfcode"
+)
+
+func asm(args []string) error {
+	fs := flag.NewFlagSet("asm", flag.ExitOnError)
+	decode := fs.Bool("decode", false, "turn a numeric program into mnemonics instead")
+	inputPath := fs.String("input", "", "path to the program, - reads stdin (default day19.input, or the program in day16.input with -decode)")
+	samplesPath := fs.String("samples", "", "path to the samples that number the ops (default day16.input)")
+	table := fs.String("ops", "puzzle", "ops to match the samples against: puzzle, extended or a comma separated list of names")
+	fs.Parse(args)
+
+	ops, err := parseOps(*table)
+	if err != nil {
+		return err
+	}
+	d16, ok := aoc.Lookup(16)
+	if !ok {
+		return fmt.Errorf("no solution for day %d", 16)
+	}
+	samples, err := readInput(d16, *samplesPath)
+	if err != nil {
+		return err
+	}
+
+	if *decode && *inputPath == "" {
+		p, err := day16.Program(samples, ops)
+		if err != nil {
+			return withFile(err, inputName(d16, *samplesPath))
+		}
+		fmt.Print(p)
+		return nil
+	}
+	opcodes, err := day16.Opcodes(samples, ops)
+	if err != nil {
+		return withFile(err, inputName(d16, *samplesPath))
+	}
+	day := 19
+	if *decode {
+		day = 16
+	}
+	d, ok := aoc.Lookup(day)
+	if !ok {
+		return fmt.Errorf("no solution for day %d", day)
+	}
+	input, err := readInput(d, *inputPath)
+	if err != nil {
+		return err
+	}
+	if *decode {
+		p, err := elfcode.Decode(input, opcodes)
+		if err != nil {
+			return withFile(err, inputName(d, *inputPath))
+		}
+		fmt.Print(p)
+		return nil
+	}
+	p, err := elfcode.ParseWith(input, ops)
+	if err != nil {
+		return withFile(err, inputName(d, *inputPath))
+	}
+	numeric, err := elfcode.Encode(p, opcodes)
+	if err != nil {
+		return err
+	}
+	fmt.Print(numeric)
+	return nil
+}
+
+func samples(args []string) error {
+	fs := flag.NewFlagSet("samples", flag.ExitOnError)
+	n := fs.Int("n", 800, "number of samples")
+	seed := fs.Int64("seed", 1, "seed for the opcodes and samples")
+	table := fs.String("ops", "puzzle", "ops to number: puzzle, extended or a comma separated list of names")
+	programPath := fs.String("program", "", "path to a mnemonic program without #ip to encode after the samples, - reads stdin")
+	fs.Parse(args)
+
+	ops, err := parseOps(*table)
+	if err != nil {
+		return err
+	}
+	rng := rand.New(rand.NewSource(*seed))
+	opcodes := elfcode.RandomOpcodes(rng, ops)
+	out := elfcode.Samples(rng, opcodes, *n)
+	if *programPath != "" {
+		d, ok := aoc.Lookup(19)
+		if !ok {
+			return fmt.Errorf("no solution for day %d", 19)
+		}
+		input, err := readInput(d, *programPath)
+		if err != nil {
+			return err
+		}
+		p, err := elfcode.ParseWith(input, ops)
+		if err != nil {
+			return withFile(err, *programPath)
+		}
+		// day 16 has no #ip line, its programs run without a bound register
+		if p.IPRegister >= 0 {
+			return fmt.Errorf("%s: #ip %d binds the instruction pointer, which a day 16 program cannot", *programPath, p.IPRegister)
+		}
+		numeric, err := elfcode.Encode(p, opcodes)
+		if err != nil {
+			return err
+		}
+		out += "\n\n\n" + numeric
+	}
+	fmt.Print(out)
+
+	// the answer key goes to stderr, to check a solver against
+	numbers := []int{}
+	for k := range opcodes {
+		numbers = append(numbers, k)
+	}
+	sort.Ints(numbers)
+	for _, k := range numbers {
+		fmt.Fprintf(os.Stderr, "opcode %2d: %s\n", k, opcodes[k])
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	day15 "github.com/deosjr/adventofcode2018/15"
+	"github.com/deosjr/adventofcode2018/aoc"
+)
+
+func battle(args []string) error {
+	fs := flag.NewFlagSet("battle", flag.ExitOnError)
+	inputPath := fs.String("input", "", "path to a map or scenario, - reads stdin (default day15.input)")
+	attack := fs.Int("attack", 0, "attack power of the elves, 0 keeps the one from the input")
+	minimal := fs.Bool("minimal", false, "use the lowest attack power at which no elf dies")
+	watch := fs.Bool("watch", false, "animate the battle in the terminal")
+	delay := fs.Duration("delay", 50*time.Millisecond, "time between turns with -watch")
+	eventsPath := fs.String("events", "", "path to write every event to as JSON lines")
+	replayPath := fs.String("replay", "", "path to a JSON lines log to check against the map instead of playing")
+	fs.Parse(args)
+
+	d, ok := aoc.Lookup(15)
+	if !ok {
+		return fmt.Errorf("no solution for day %d", 15)
+	}
+	input, err := readInput(d, *inputPath)
+	if err != nil {
+		return err
+	}
+	if *minimal {
+		power, _, err := day15.MinimalAttack(input)
+		if err != nil {
+			return withFile(err, inputName(d, *inputPath))
+		}
+		if !*watch {
+			fmt.Printf("attack power: %d\n", power)
+		}
+		*attack = power
+	}
+
+	if *replayPath != "" {
+		f, err := os.Open(*replayPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		events, err := day15.ReadJSONLines(f)
+		if err != nil {
+			return fmt.Errorf("%s: %w", *replayPath, err)
+		}
+		outcome, err := day15.Replay(input, *attack, events)
+		if err != nil {
+			return withFile(err, inputName(d, *inputPath))
+		}
+		fmt.Printf("%d events replayed, outcome: %d\n", len(events), outcome)
+		return nil
+	}
+
+	var sink day15.Sink
+	var jsonLines *day15.JSONLines
+	if *eventsPath != "" {
+		f, err := os.Create(*eventsPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		jsonLines = day15.NewJSONLines(f)
+		sink = jsonLines
+	}
+	var last day15.Turn
+	turn := func(t day15.Turn) bool {
+		last = t
+		return true
+	}
+	if *watch {
+		if *inputPath == "-" {
+			return fmt.Errorf("-watch reads keys from stdin, so the map cannot come from stdin")
+		}
+		w := newWatcher(*delay)
+		defer w.close()
+		turn = w.turn
+	}
+	outcome, err := day15.Watch(input, *attack, turn, sink)
+	if jsonLines != nil && jsonLines.Err() != nil {
+		return jsonLines.Err()
+	}
+	if err == day15.ErrStopped {
+		return nil
+	}
+	if err != nil {
+		return withFile(err, inputName(d, *inputPath))
+	}
+	if !*watch && last.Mover != nil {
+		fmt.Print(last)
+	}
+	fmt.Printf("outcome: %d\n", outcome)
+	return nil
+}
+
+// watcher draws every turn and lets the keyboard pause, step and change speed
+type watcher struct {
+	delay  time.Duration
+	paused bool
+	keys   chan byte
+	// restore puts the terminal back the way it was
+	restore func()
+}
+
+const watchHelp = "space pause, n step, + faster, - slower, q quit"
+
+func newWatcher(delay time.Duration) *watcher {
+	w := &watcher{delay: delay, keys: make(chan byte), restore: func() {}}
+	// read keys without waiting for enter, if stdin is a terminal
+	if state, err := stty("-g"); err == nil {
+		if _, err := stty("cbreak", "-echo"); err == nil {
+			w.restore = func() { stty(strings.TrimSpace(state)) }
+		}
+	}
+	go func() {
+		r := bufio.NewReader(os.Stdin)
+		for {
+			b, err := r.ReadByte()
+			if err != nil {
+				close(w.keys)
+				return
+			}
+			w.keys <- b
+		}
+	}()
+	// clear the screen and hide the cursor
+	fmt.Print("\x1b[2J\x1b[?25l")
+	return w
+}
+
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+func (w *watcher) close() {
+	fmt.Print("\x1b[?25h")
+	w.restore()
+}
+
+// turn draws the frame, then waits for the delay to pass,
+// or for a key to step or resume when paused
+func (w *watcher) turn(t day15.Turn) bool {
+	w.draw(t)
+	timeout := time.After(w.delay)
+	for {
+		if w.paused {
+			timeout = nil
+		}
+		select {
+		case <-timeout:
+			return true
+		case key, ok := <-w.keys:
+			if !ok {
+				// stdin is closed, keep playing without controls
+				w.keys, w.paused = nil, false
+				timeout = time.After(w.delay)
+				continue
+			}
+			switch key {
+			case ' ':
+				w.paused = !w.paused
+				if !w.paused {
+					return true
+				}
+			case 'n':
+				w.paused = true
+				return true
+			case '+':
+				if w.delay > time.Millisecond {
+					w.delay /= 2
+				}
+			case '-':
+				if w.delay < 2*time.Second {
+					w.delay *= 2
+				}
+			case 'q':
+				return false
+			default:
+				continue
+			}
+			w.draw(t)
+		}
+	}
+}
+
+func (w *watcher) draw(t day15.Turn) {
+	state := fmt.Sprintf("delay %v", w.delay)
+	if w.paused {
+		state = "paused"
+	}
+	frame := strings.ReplaceAll(t.Frame(), "\n", "\x1b[K\n")
+	// move to the top left, draw over the last frame and clear what is left of it
+	fmt.Printf("\x1b[Hround %d  %s\x1b[K\n%s%s\x1b[K\n\x1b[J", t.Round, state, frame, watchHelp)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	day13 "github.com/deosjr/adventofcode2018/13"
+	"github.com/deosjr/adventofcode2018/aoc"
+)
+
+func carts(args []string) error {
+	fs := flag.NewFlagSet("carts", flag.ExitOnError)
+	inputPath := fs.String("input", "", "path to a track map, - reads stdin (default day13.input)")
+	fs.Parse(args)
+
+	d, ok := aoc.Lookup(13)
+	if !ok {
+		return fmt.Errorf("no solution for day %d", 13)
+	}
+	input, err := readInput(d, *inputPath)
+	if err != nil {
+		return err
+	}
+	t, err := day13.Run(input)
+	if err != nil {
+		return withFile(err, inputName(d, *inputPath))
+	}
+	for _, c := range t.Crashes {
+		fmt.Printf("tick %d: cart %d runs into cart %d at %d,%d\n", c.Tick, c.Carts[0], c.Carts[1], c.Pos.X, c.Pos.Y)
+	}
+	if t.Survivor == -1 {
+		fmt.Printf("no cart is left after tick %d\n", t.Ticks)
+		return nil
+	}
+	fmt.Printf("cart %d is left after tick %d, its path:\n", t.Survivor, t.Ticks)
+	for _, w := range t.Path {
+		fmt.Printf("tick %d: %d,%d heading %s\n", w.Tick, w.Pos.X, w.Pos.Y, w.Heading)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	day19 "github.com/deosjr/adventofcode2018/19"
+	"github.com/deosjr/adventofcode2018/aoc"
+)
+
+// elfcodeDays are the days whose input is an elfcode program
+var elfcodeDays = map[int]bool{19: true, 21: true}
+
+func compile(args []string) error {
+	fs := flag.NewFlagSet("compile", flag.ExitOnError)
+	day := fs.Int("day", 19, "elfcode day to compile, 19 or 21")
+	inputPath := fs.String("input", "", "path to an elfcode program, - reads stdin (default dayN.input)")
+	pkg := fs.String("package", "main", "package of the generated file")
+	fn := fs.String("func", "run", "name of the generated function")
+	fs.Parse(args)
+
+	if !elfcodeDays[*day] {
+		return fmt.Errorf("day %d is not an elfcode day", *day)
+	}
+	d, ok := aoc.Lookup(*day)
+	if !ok {
+		return fmt.Errorf("no solution for day %d", *day)
+	}
+	input, err := readInput(d, *inputPath)
+	if err != nil {
+		return err
+	}
+	src, err := day19.Compile(input, *pkg, *fn)
+	if err != nil {
+		return withFile(err, inputName(d, *inputPath))
+	}
+	fmt.Print(src)
+	return nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/deosjr/adventofcode2018/aoc"
+	"github.com/deosjr/adventofcode2018/elfcode"
+)
+
+func debug(args []string) error {
+	fs := flag.NewFlagSet("debug", flag.ExitOnError)
+	day := fs.Int("day", 19, "elfcode day to debug, 19 or 21")
+	inputPath := fs.String("input", "", "path to an elfcode program (default dayN.input)")
+	r0 := fs.Int("r0", 0, "initial value of register 0")
+	traceLen := fs.Int("trace", 20, "number of executed instructions to keep in the trace")
+	fs.Parse(args)
+
+	if !elfcodeDays[*day] {
+		return fmt.Errorf("day %d is not an elfcode day", *day)
+	}
+	if *inputPath == "-" {
+		return errors.New("stdin is used for debugger commands, -input cannot be -")
+	}
+	d, ok := aoc.Lookup(*day)
+	if !ok {
+		return fmt.Errorf("no solution for day %d", *day)
+	}
+	input, err := readInput(d, *inputPath)
+	if err != nil {
+		return err
+	}
+	p, err := elfcode.Parse(input)
+	if err != nil {
+		return withFile(err, inputName(d, *inputPath))
+	}
+	vm := elfcode.New(p, elfcode.Registers)
+	vm.Registers[0] = *r0
+	return elfcode.NewDebugger(vm, *traceLen).REPL(os.Stdin, os.Stdout)
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/deosjr/adventofcode2018/aoc"
+	"github.com/deosjr/adventofcode2018/elfcode"
+)
+
+func disasm(args []string) error {
+	fs := flag.NewFlagSet("disasm", flag.ExitOnError)
+	day := fs.Int("day", 19, "elfcode day to disassemble, 19 or 21")
+	inputPath := fs.String("input", "", "path to an elfcode program, - reads stdin (default dayN.input)")
+	fs.Parse(args)
+
+	if !elfcodeDays[*day] {
+		return fmt.Errorf("day %d is not an elfcode day", *day)
+	}
+	d, ok := aoc.Lookup(*day)
+	if !ok {
+		return fmt.Errorf("no solution for day %d", *day)
+	}
+	input, err := readInput(d, *inputPath)
+	if err != nil {
+		return err
+	}
+	p, err := elfcode.Parse(input)
+	if err != nil {
+		return withFile(err, inputName(d, *inputPath))
+	}
+	fmt.Print(elfcode.Disassemble(p))
+	return nil
+}
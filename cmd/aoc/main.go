@@ -0,0 +1,124 @@
+// Command aoc runs the Advent of Code 2018 solutions.
+//
+// Usage:
+//
+//	aoc run [-day n] [-part n] [-input path] [-json]
+//	aoc asm [-decode] [-input path] [-samples path] [-ops table]
+//	aoc battle [-input path] [-attack n] [-minimal] [-watch] [-delay d] [-events path] [-replay path]
+//	aoc carts [-input path]
+//	aoc compile [-day n] [-input path] [-package name] [-func name]
+//	aoc disasm [-day n] [-input path]
+//	aoc debug [-day n] [-input path] [-r0 n] [-trace n]
+//	aoc opcodes [-input path] [-ops table]
+//	aoc profile [-day n] [-input path] [-r0 n] [-steps n] [-dot path]
+//	aoc samples [-n count] [-seed n] [-ops table] [-program path]
+//	aoc sweep [-input path] [-from n] [-to n] [-workers n] [-csv]
+//
+// Run from the root of the repository, each day reads its own dayN.input
+// unless another input is given. An input of - reads from stdin.
+// With -json every answer is printed as a JSON object on its own line,
+// together with the sha256 of the input and the wall time in nanoseconds.
+// Malformed input stops the run with the file and line that failed to parse.
+//
+// asm encodes a mnemonic elfcode program, day 19 unless another is given,
+// into the numeric form of day 16, numbering the ops the way the samples of
+// day 16 do. With -decode it turns a numeric program, by default the test
+// program of day 16, back into mnemonics.
+//
+// battle plays out the battle of day 15 with the elves at -attack power,
+// printing the final map and the outcome. The map may be preceded by a
+// scenario declaring other factions, who fights whom and the stats of
+// single units. With -minimal the elves get the
+// lowest attack power at which none of them dies instead. With -watch the map is redrawn
+// in the terminal after every turn, highlighting the unit whose turn it is
+// and the unit it attacks. Space pauses and resumes, n steps a single turn,
+// + and - change the speed and q quits. With -events every move, attack,
+// death and the end of every round is written to a file as JSON lines,
+// and -replay checks such a log against the map instead of playing,
+// with the elves at the attack power given by -attack or -minimal.
+//
+// carts lets the mine carts of day 13 run until at most one is left and
+// prints every crash with its tick, place and the carts involved, followed
+// by the path of the cart that is left: where it started, every place it
+// turned and where it ended up.
+//
+// compile turns the elfcode program of day 19 or 21 into a Go source file
+// with a single function that takes and returns the register file.
+//
+// disasm prints the elfcode program of day 19 or 21 as pseudocode with
+// labels, gotos and conditional jumps, marking instructions that are dead.
+//
+// debug steps through the elfcode program of day 19 or 21 with breakpoints,
+// register watches and a trace, reading commands from stdin. Type help
+// for a list of commands.
+//
+// opcodes matches the samples of day 16 against a table of ops, the puzzle
+// ones, an extended table with division, modulo and shifts or a list of
+// op names, and reports what each opcode behaves like, how many samples are
+// ambiguous and a set of samples that is enough to determine every opcode.
+//
+// profile runs the elfcode program of day 19 or 21 for at most -steps
+// instructions and prints a listing annotated with execution counts,
+// a heatmap of register writes and the back-edges taken. With -dot the
+// control flow graph is written in Graphviz format as well.
+//
+// samples generates -n Before/After samples in the format of day 16 for
+// a random numbering of the ops, optionally followed by a program encoded
+// with that numbering, to test the opcode solver on. Like the program of
+// day 16 it cannot bind the instruction pointer. The numbering itself
+// is printed to stderr.
+//
+// sweep fights the battle of day 15 once for every elf attack power from
+// -from to -to, several at the same time, and prints a table or CSV with
+// the rounds, hit points left, outcome and survivors per faction for each,
+// together with the elves that died in the order they did.
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+var commands = map[string]func(args []string) error{
+	"run":     run,
+	"asm":     asm,
+	"battle":  battle,
+	"carts":   carts,
+	"compile": compile,
+	"debug":   debug,
+	"disasm":  disasm,
+	"opcodes": opcodes,
+	"profile": profile,
+	"samples": samples,
+	"sweep":   sweep,
+}
+
+func usage() {
+	names := []string{}
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(os.Stderr, "usage: aoc <command> [flags]")
+	fmt.Fprintln(os.Stderr, "commands:")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "\t%s\n", name)
+	}
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+	if err := cmd(os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "aoc %s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	day16 "github.com/deosjr/adventofcode2018/16"
+	"github.com/deosjr/adventofcode2018/aoc"
+	"github.com/deosjr/adventofcode2018/elfcode"
+)
+
+// parseOps returns the named table of ops or the ops in a comma separated list,
+// which may name any of the extended ops
+func parseOps(table string) ([]elfcode.Op, error) {
+	if ops, ok := opTables[table]; ok {
+		return ops, nil
+	}
+	byName := map[string]elfcode.Op{}
+	for _, op := range elfcode.Extended {
+		byName[op.Name] = op
+	}
+	var ops []elfcode.Op
+	for _, name := range strings.Split(table, ",") {
+		op, ok := byName[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown op %q", name)
+		}
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+// opTables are the tables of ops samples can be matched against by name
+var opTables = map[string][]elfcode.Op{
+	"puzzle":   elfcode.Ops,
+	"extended": elfcode.Extended,
+}
+
+func opcodes(args []string) error {
+	fs := flag.NewFlagSet("opcodes", flag.ExitOnError)
+	inputPath := fs.String("input", "", "path to a file with samples, - reads stdin (default day16.input)")
+	table := fs.String("ops", "puzzle", "ops to match against: puzzle, extended or a comma separated list of names")
+	fs.Parse(args)
+
+	ops, err := parseOps(*table)
+	if err != nil {
+		return err
+	}
+	d, ok := aoc.Lookup(16)
+	if !ok {
+		return fmt.Errorf("no solution for day %d", 16)
+	}
+	input, err := readInput(d, *inputPath)
+	if err != nil {
+		return err
+	}
+	report, err := day16.Report(input, ops)
+	if err != nil {
+		return withFile(err, inputName(d, *inputPath))
+	}
+	fmt.Print(report)
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/deosjr/adventofcode2018/aoc"
+	"github.com/deosjr/adventofcode2018/elfcode"
+)
+
+func profile(args []string) error {
+	fs := flag.NewFlagSet("profile", flag.ExitOnError)
+	day := fs.Int("day", 19, "elfcode day to profile, 19 or 21")
+	inputPath := fs.String("input", "", "path to an elfcode program, - reads stdin (default dayN.input)")
+	r0 := fs.Int("r0", 0, "initial value of register 0")
+	steps := fs.Int("steps", 10000000, "maximum number of instructions to execute")
+	dotPath := fs.String("dot", "", "also write the control flow graph in DOT format to this file")
+	fs.Parse(args)
+
+	if !elfcodeDays[*day] {
+		return fmt.Errorf("day %d is not an elfcode day", *day)
+	}
+	d, ok := aoc.Lookup(*day)
+	if !ok {
+		return fmt.Errorf("no solution for day %d", *day)
+	}
+	input, err := readInput(d, *inputPath)
+	if err != nil {
+		return err
+	}
+	p, err := elfcode.Parse(input)
+	if err != nil {
+		return withFile(err, inputName(d, *inputPath))
+	}
+	vm := elfcode.New(p, elfcode.Registers)
+	vm.Registers[0] = *r0
+	prof := elfcode.Record(vm, *steps)
+	prof.Listing(os.Stdout)
+	if *dotPath == "" {
+		return nil
+	}
+	f, err := os.Create(*dotPath)
+	if err != nil {
+		return err
+	}
+	prof.DOT(f)
+	return f.Close()
+}
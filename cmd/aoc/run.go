@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/deosjr/adventofcode2018/aoc"
+	"github.com/deosjr/adventofcode2018/scan"
+)
+
+func run(args []string) error {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	day := fs.Int("day", 0, "day to run, 0 runs all days")
+	part := fs.Int("part", 0, "part to run, 0 runs both parts")
+	inputPath := fs.String("input", "", "path to the puzzle input, - reads stdin (default dayN.input)")
+	asJSON := fs.Bool("json", false, "print one JSON result per line instead of text")
+	fs.Parse(args)
+
+	if *part < 0 || *part > 2 {
+		return fmt.Errorf("invalid part %d", *part)
+	}
+	days := aoc.Days()
+	if *day != 0 {
+		d, ok := aoc.Lookup(*day)
+		if !ok {
+			return fmt.Errorf("no solution for day %d", *day)
+		}
+		if *part != 0 && d.Part(*part) == nil {
+			return fmt.Errorf("day %d has no part %d", *day, *part)
+		}
+		days = []aoc.Day{d}
+	} else if *inputPath != "" {
+		return errors.New("-input can only be used together with -day")
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	for _, d := range days {
+		input, err := readInput(d, *inputPath)
+		if err != nil {
+			return err
+		}
+		if !*asJSON {
+			fmt.Printf("Day %d\n", d.Number)
+		}
+		for p := 1; p <= 2; p++ {
+			if *part != 0 && *part != p {
+				continue
+			}
+			if d.Part(p) == nil {
+				continue
+			}
+			result, err := d.Solve(p, input)
+			if err != nil {
+				err = withFile(err, inputName(d, *inputPath))
+				return fmt.Errorf("day %d part %d: %w", d.Number, p, err)
+			}
+			if *asJSON {
+				if err := enc.Encode(result); err != nil {
+					return err
+				}
+				continue
+			}
+			fmt.Println(result)
+		}
+	}
+	return nil
+}
+
+// withFile fills in the file name if err is a parse error
+func withFile(err error, name string) error {
+	var perr *scan.ParseError
+	if errors.As(err, &perr) {
+		perr.File = name
+	}
+	return err
+}
+
+// inputName returns where the input of d is read from, for use in errors
+func inputName(d aoc.Day, path string) string {
+	switch path {
+	case "":
+		return d.Input()
+	case "-":
+		return "stdin"
+	}
+	return path
+}
+
+func readInput(d aoc.Day, path string) (string, error) {
+	if path == "" {
+		path = d.Input()
+	}
+	var input []byte
+	var err error
+	if path == "-" {
+		input, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		input, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(input), nil
+}
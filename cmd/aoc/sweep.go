@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	day15 "github.com/deosjr/adventofcode2018/15"
+	"github.com/deosjr/adventofcode2018/aoc"
+)
+
+func sweep(args []string) error {
+	fs := flag.NewFlagSet("sweep", flag.ExitOnError)
+	inputPath := fs.String("input", "", "path to a map or scenario, - reads stdin (default day15.input)")
+	from := fs.Int("from", 3, "lowest attack power of the elves")
+	to := fs.Int("to", 40, "highest attack power of the elves")
+	workers := fs.Int("workers", runtime.NumCPU(), "number of battles fought at the same time")
+	asCSV := fs.Bool("csv", false, "print CSV instead of a table")
+	fs.Parse(args)
+	if *from < 1 || *workers < 1 {
+		return fmt.Errorf("-from and -workers should be at least 1")
+	}
+
+	d, ok := aoc.Lookup(15)
+	if !ok {
+		return fmt.Errorf("no solution for day %d", 15)
+	}
+	input, err := readInput(d, *inputPath)
+	if err != nil {
+		return err
+	}
+	results, err := day15.Sweep(input, *from, *to, *workers)
+	if err != nil {
+		return withFile(err, inputName(d, *inputPath))
+	}
+	if *asCSV {
+		return writeSweepCSV(os.Stdout, results)
+	}
+	return writeSweepTable(os.Stdout, results)
+}
+
+// sweepHeader names the columns, with one per faction for its survivors
+func sweepHeader(r day15.Result) []string {
+	header := []string{"power", "rounds", "hp", "outcome"}
+	for _, s := range r.Survivors {
+		header = append(header, s.Name)
+	}
+	return append(header, "elves lost", "elf deaths")
+}
+
+// sweepRow lists the elf deaths as unit@round
+func sweepRow(r day15.Result) []string {
+	row := []string{strconv.Itoa(r.Power), strconv.Itoa(r.Rounds), strconv.Itoa(r.HP), strconv.Itoa(r.Outcome)}
+	for _, s := range r.Survivors {
+		row = append(row, strconv.Itoa(s.Units))
+	}
+	deaths := []string{}
+	for _, d := range r.ElfDeaths {
+		deaths = append(deaths, fmt.Sprintf("%d@%d", d.Unit, d.Round))
+	}
+	return append(row, strconv.Itoa(len(r.ElfDeaths)), strings.Join(deaths, " "))
+}
+
+func writeSweepTable(w io.Writer, results []day15.Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(sweepHeader(results[0]), "\t"))
+	for _, r := range results {
+		fmt.Fprintln(tw, strings.Join(sweepRow(r), "\t"))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w, "elf deaths are listed as unit@round, in the order they died")
+	return err
+}
+
+func writeSweepCSV(w io.Writer, results []day15.Result) error {
+	cw := csv.NewWriter(w)
+	header := sweepHeader(results[0])
+	for i, h := range header {
+		header[i] = strings.ReplaceAll(h, " ", "_")
+	}
+	cw.Write(header)
+	for _, r := range results {
+		cw.Write(sweepRow(r))
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,110 @@
+// Package cycle finds cycles in sequences x0, x1 = f(x0), x2 = f(x1), ...
+// that eventually repeat, either in constant memory (Floyd, Brent) or by
+// remembering a key for every value seen (Hash).
+package cycle
+
+// Cycle describes an eventually periodic sequence: from step Start on,
+// the sequence repeats itself every Length steps
+type Cycle struct {
+	Start, Length int
+}
+
+// Index returns the step before the first repeat that has the same value as step n
+func (c Cycle) Index(n int) int {
+	if n < c.Start {
+		return n
+	}
+	return c.Start + (n-c.Start)%c.Length
+}
+
+// LastUnique returns the step of the last value before the sequence repeats
+func (c Cycle) LastUnique() int {
+	return c.Start + c.Length - 1
+}
+
+// Floyd finds the cycle using a tortoise and a hare running at double its speed.
+// It returns the cycle and the last value before the sequence repeats
+func Floyd[T comparable](x0 T, f func(T) T) (Cycle, T) {
+	tortoise, hare := f(x0), f(f(x0))
+	for tortoise != hare {
+		tortoise, hare = f(tortoise), f(f(hare))
+	}
+	// the distance from x0 to the tortoise is a multiple of the length,
+	// so walking both from x0 and the tortoise they meet at the start
+	start := 0
+	tortoise = x0
+	for tortoise != hare {
+		tortoise, hare = f(tortoise), f(hare)
+		start++
+	}
+	length := 1
+	for hare = f(tortoise); tortoise != hare; hare = f(hare) {
+		length++
+	}
+	return Cycle{start, length}, last(x0, start, length, f)
+}
+
+// Brent finds the cycle by teleporting the tortoise to the hare every
+// power of two steps. It calls f fewer times than Floyd does.
+// It returns the cycle and the last value before the sequence repeats
+func Brent[T comparable](x0 T, f func(T) T) (Cycle, T) {
+	power, length := 1, 1
+	tortoise, hare := x0, f(x0)
+	for tortoise != hare {
+		if power == length {
+			tortoise = hare
+			power *= 2
+			length = 0
+		}
+		hare = f(hare)
+		length++
+	}
+	// the hare starts length steps ahead, so they meet at the start
+	tortoise, hare = x0, x0
+	for i := 0; i < length; i++ {
+		hare = f(hare)
+	}
+	start := 0
+	for tortoise != hare {
+		tortoise, hare = f(tortoise), f(hare)
+		start++
+	}
+	return Cycle{start, length}, last(x0, start, length, f)
+}
+
+// last walks from x0 to the last value before the sequence repeats
+func last[T any](x0 T, start, length int, f func(T) T) T {
+	x := x0
+	for i := 0; i < start+length-1; i++ {
+		x = f(x)
+	}
+	return x
+}
+
+// Hash finds the cycle by remembering the step at which each key was seen.
+// It calls f only until the first repeat, once per step and in order,
+// and works on values that are not comparable through their key.
+// It returns the cycle and the last value before the sequence repeats
+func Hash[T any, K comparable](x0 T, f func(T) T, key func(T) K) (Cycle, T) {
+	c, x, _ := HashN(x0, f, key, -1)
+	return c, x
+}
+
+// HashN is Hash for a sequence that is only needed up to step n and
+// might never repeat. If there is no repeat up to step n it stops there,
+// returning false and the value at step n. A negative n never stops
+func HashN[T any, K comparable](x0 T, f func(T) T, key func(T) K, n int) (Cycle, T, bool) {
+	seen := map[K]int{}
+	x, prev := x0, x0
+	for step := 0; ; step++ {
+		k := key(x)
+		if first, ok := seen[k]; ok {
+			return Cycle{first, step - first}, prev, true
+		}
+		if step == n {
+			return Cycle{}, x, false
+		}
+		seen[k] = step
+		prev, x = x, f(x)
+	}
+}
@@ -0,0 +1,96 @@
+package cycle
+
+import "testing"
+
+// naive finds the cycle by storing the whole sequence
+func naive(x0 int, f func(int) int) (Cycle, int) {
+	seq := []int{x0}
+	for {
+		x := f(seq[len(seq)-1])
+		for i, y := range seq {
+			if x == y {
+				return Cycle{i, len(seq) - i}, seq[len(seq)-1]
+			}
+		}
+		seq = append(seq, x)
+	}
+}
+
+func TestCycle(t *testing.T) {
+	identity := func(x int) int { return x }
+	for _, tt := range []struct {
+		name string
+		x0   int
+		f    func(int) int
+	}{
+		{"fixed point", 3, func(x int) int { return x }},
+		{"pure cycle", 0, func(x int) int { return (x + 1) % 7 }},
+		{"tail then cycle", 2, func(x int) int { return (x*x + 1) % 255 }},
+		{"long tail", 1, func(x int) int {
+			if x < 100 {
+				return x + 1
+			}
+			return 90 + (x-90+1)%11
+		}},
+	} {
+		want, wantLast := naive(tt.x0, tt.f)
+		for _, alg := range []struct {
+			name string
+			find func(int, func(int) int) (Cycle, int)
+		}{
+			{"Floyd", Floyd[int]},
+			{"Brent", Brent[int]},
+			{"Hash", func(x0 int, f func(int) int) (Cycle, int) { return Hash(x0, f, identity) }},
+		} {
+			got, last := alg.find(tt.x0, tt.f)
+			if got != want || last != wantLast {
+				t.Errorf("%s %s: got %+v last %d want %+v last %d", alg.name, tt.name, got, last, want, wantLast)
+			}
+		}
+	}
+}
+
+func TestHashCallsInOrder(t *testing.T) {
+	steps := []int{}
+	f := func(x int) int {
+		steps = append(steps, x)
+		return (x + 2) % 6
+	}
+	c, last := Hash(1, f, func(x int) int { return x })
+	if c != (Cycle{0, 3}) || last != 5 {
+		t.Errorf("got %+v last %d", c, last)
+	}
+	if len(steps) != 3 || steps[0] != 1 || steps[1] != 3 || steps[2] != 5 {
+		t.Errorf("got calls %v", steps)
+	}
+}
+
+func TestHashN(t *testing.T) {
+	identity := func(x int) int { return x }
+	calls := 0
+	count := func(x int) int {
+		calls++
+		return x + 1
+	}
+	// a sequence that never repeats stops at step n
+	if _, x, ok := HashN(0, count, identity, 20); ok || x != 20 || calls != 20 {
+		t.Errorf("got %d, %t after %d calls", x, ok, calls)
+	}
+	// a repeat before step n is a cycle as found by Hash
+	c, last, ok := HashN(1, func(x int) int { return (x + 2) % 6 }, identity, 20)
+	if !ok || c != (Cycle{0, 3}) || last != 5 {
+		t.Errorf("got %+v last %d, %t", c, last, ok)
+	}
+}
+
+func TestIndex(t *testing.T) {
+	c := Cycle{Start: 3, Length: 4}
+	for n, want := range map[int]int{0: 0, 2: 2, 3: 3, 6: 6, 7: 3, 9: 5, 1000000000: 3 + (1000000000-3)%4} {
+		if got := c.Index(n); got != want {
+			t.Errorf("Index(%d): got %d want %d", n, got, want)
+		}
+	}
+	if got := c.LastUnique(); got != 6 {
+		t.Errorf("LastUnique: got %d want 6", got)
+	}
+}
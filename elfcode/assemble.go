@@ -0,0 +1,125 @@
+package elfcode
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/deosjr/adventofcode2018/scan"
+)
+
+// String prints the program in mnemonic form, the way Parse reads it
+func (p Program) String() string {
+	var sb strings.Builder
+	if p.IPRegister >= 0 {
+		fmt.Fprintf(&sb, "#ip %d\n", p.IPRegister)
+	}
+	for _, ins := range p.Instructions {
+		fmt.Fprintln(&sb, ins)
+	}
+	return sb.String()
+}
+
+// Encode prints the program in the numeric form of day 16, one
+// opcode a b c line per instruction, using opcodes to number the ops.
+// A bound register is kept as an #ip line on top
+func Encode(p Program, opcodes map[int]Op) (string, error) {
+	numbers := map[string]int{}
+	for n, op := range opcodes {
+		numbers[op.Name] = n
+	}
+	var sb strings.Builder
+	if p.IPRegister >= 0 {
+		fmt.Fprintf(&sb, "#ip %d\n", p.IPRegister)
+	}
+	for i, ins := range p.Instructions {
+		n, ok := numbers[ins.Op.Name]
+		if !ok {
+			return "", fmt.Errorf("instruction %d: no opcode for %s", i, ins.Op.Name)
+		}
+		fmt.Fprintf(&sb, "%d %d %d %d\n", n, ins.A, ins.B, ins.C)
+	}
+	return sb.String(), nil
+}
+
+// Decode reads a program in numeric form, starting with an optional #ip line,
+// looking up the op behind each number in opcodes
+func Decode(input string, opcodes map[int]Op) (Program, error) {
+	p := Program{IPRegister: -1}
+	r := scan.NewReader(input)
+	for r.Scan() {
+		if strings.HasPrefix(r.Text(), "#ip") {
+			if r.Line() != 1 {
+				return Program{}, r.Errorf("#ip n", "#ip should be on the first line")
+			}
+			if err := r.Scanf("#ip %d", &p.IPRegister); err != nil {
+				return Program{}, err
+			}
+			if p.IPRegister < 0 || p.IPRegister >= Registers {
+				return Program{}, r.Errorf("#ip %d", "register %d out of range, there are %d registers", p.IPRegister, Registers)
+			}
+			continue
+		}
+		var n int
+		var i Instruction
+		if err := r.Scanf("%d %d %d %d", &n, &i.A, &i.B, &i.C); err != nil {
+			return Program{}, err
+		}
+		op, ok := opcodes[n]
+		if !ok {
+			return Program{}, r.Errorf("%d %d %d %d", "unknown opcode %d", n)
+		}
+		i.Op = op
+		if err := i.Check(Registers); err != nil {
+			return Program{}, r.Error("%d %d %d %d", err)
+		}
+		p.Instructions = append(p.Instructions, i)
+	}
+	return p, nil
+}
+
+// RandomOpcodes numbers the ops 0 to len(ops)-1 in a random order
+func RandomOpcodes(rng *rand.Rand, ops []Op) map[int]Op {
+	opcodes := map[int]Op{}
+	for n, i := range rng.Perm(len(ops)) {
+		opcodes[n] = ops[i]
+	}
+	return opcodes
+}
+
+// Samples prints n Before/After samples as in day 16: four registers
+// holding values below 16, and an instruction with operands below 4 using
+// a random opcode. Instructions that panic, such as dividing by zero,
+// are drawn again
+func Samples(rng *rand.Rand, opcodes map[int]Op, n int) string {
+	numbers := make([]int, 0, len(opcodes))
+	for k := range opcodes {
+		numbers = append(numbers, k)
+	}
+	// map order is random, sort to make the samples depend on rng only
+	sort.Ints(numbers)
+	var sb strings.Builder
+	for len(numbers) > 0 && n > 0 {
+		before := make([]int, 4)
+		for i := range before {
+			before[i] = rng.Intn(16)
+		}
+		number := numbers[rng.Intn(len(numbers))]
+		ins := Instruction{Op: opcodes[number], A: rng.Intn(4), B: rng.Intn(4), C: rng.Intn(4)}
+		after := append([]int{}, before...)
+		value, ok := evaluate(ins.Op, after, ins.A, ins.B)
+		if !ok {
+			continue
+		}
+		after[ins.C] = value
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "Before: [%d, %d, %d, %d]\n", before[0], before[1], before[2], before[3])
+		fmt.Fprintf(&sb, "%d %d %d %d\n", number, ins.A, ins.B, ins.C)
+		fmt.Fprintf(&sb, "After:  [%d, %d, %d, %d]\n", after[0], after[1], after[2], after[3])
+		n--
+	}
+	return sb.String()
+}
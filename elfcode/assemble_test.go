@@ -0,0 +1,83 @@
+package elfcode
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	p, err := Parse(countdown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opcodes := map[int]Op{0: Gtri, 1: Addi, 2: Seti, 3: Addr}
+	got, err := Encode(p, opcodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "#ip 3\n2 0 0 2\n1 1 1 1\n0 1 4 0\n3 3 0 3\n2 0 0 3\n2 9 0 2\n"
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+	back, err := Decode(got, opcodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if back.String() != p.String() {
+		t.Errorf("decoded\n%s\nwant\n%s", back, p)
+	}
+
+	delete(opcodes, 0)
+	if _, err := Encode(p, opcodes); err == nil || err.Error() != "instruction 2: no opcode for gtri" {
+		t.Errorf("got error %v", err)
+	}
+	if _, err := Decode(want, opcodes); err == nil || !strings.Contains(err.Error(), "unknown opcode 0") {
+		t.Errorf("got error %v", err)
+	}
+	if _, err := Decode("#ip 5\n1 2 0 7", opcodes); err == nil || !strings.Contains(err.Error(), "register c=7 out of range") {
+		t.Errorf("got error %v", err)
+	}
+	if _, err := Decode("1 2 0 3\n#ip 5", opcodes); err == nil || err.Error() != `line 2: cannot parse "#ip 5" as "#ip n": #ip should be on the first line` {
+		t.Errorf("got error %v", err)
+	}
+}
+
+func TestSamples(t *testing.T) {
+	opcodes := RandomOpcodes(rand.New(rand.NewSource(1)), []Op{Divr, Modr, Addi})
+	if len(opcodes) != 3 {
+		t.Fatalf("got %v", opcodes)
+	}
+	samples := Samples(rand.New(rand.NewSource(1)), opcodes, 50)
+	if again := Samples(rand.New(rand.NewSource(1)), opcodes, 50); again != samples {
+		t.Error("same seed gave different samples")
+	}
+	blocks := strings.Split(samples, "\n\n")
+	if len(blocks) != 50 {
+		t.Fatalf("got %d samples want 50", len(blocks))
+	}
+	for _, block := range blocks {
+		var before, after [4]int
+		var n, a, b, c int
+		lines := strings.Split(strings.TrimSpace(block), "\n")
+		if len(lines) != 3 {
+			t.Fatalf("malformed sample %q", block)
+		}
+		if _, err := fmt.Sscanf(lines[0], "Before: [%d, %d, %d, %d]", &before[0], &before[1], &before[2], &before[3]); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fmt.Sscanf(lines[1], "%d %d %d %d", &n, &a, &b, &c); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fmt.Sscanf(lines[2], "After:  [%d, %d, %d, %d]", &after[0], &after[1], &after[2], &after[3]); err != nil {
+			t.Fatal(err)
+		}
+		r := before
+		Instruction{opcodes[n], a, b, c}.Execute(r[:])
+		if !reflect.DeepEqual(r, after) {
+			t.Errorf("%q: got after %v", block, r)
+		}
+	}
+}
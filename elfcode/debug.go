@@ -0,0 +1,199 @@
+package elfcode
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// Executed is a single instruction as it was run by the debugger
+type Executed struct {
+	IP          int
+	Instruction Instruction
+	Before      []int
+	After       []int
+}
+
+func (e Executed) String() string {
+	return fmt.Sprintf("%3d: %-14s %v -> %v", e.IP, e.Instruction, e.Before, e.After)
+}
+
+// Condition compares a register against a value or another register
+type Condition struct {
+	Register int
+	Operator string
+	Value    int
+	// ValueIsRegister compares against register Value instead
+	ValueIsRegister bool
+}
+
+var conditionRegex = regexp.MustCompile(`^r(\d+)\s*(==|!=|<=|>=|<|>)\s*(r?)(-?\d+)$`)
+
+// ParseCondition reads a condition like r3 == 10 or r1 > r2
+func ParseCondition(s string) (Condition, error) {
+	m := conditionRegex.FindStringSubmatch(s)
+	if m == nil {
+		return Condition{}, fmt.Errorf("invalid condition %q, want rX op value or rX op rY", s)
+	}
+	reg, err := strconv.Atoi(m[1])
+	if err != nil {
+		return Condition{}, fmt.Errorf("invalid condition %q: %w", s, err)
+	}
+	value, err := strconv.Atoi(m[4])
+	if err != nil {
+		return Condition{}, fmt.Errorf("invalid condition %q: %w", s, err)
+	}
+	c := Condition{Register: reg, Operator: m[2], Value: value, ValueIsRegister: m[3] == "r"}
+	if c.Register >= Registers || c.ValueIsRegister && (c.Value < 0 || c.Value >= Registers) {
+		return Condition{}, fmt.Errorf("invalid condition %q: there are only %d registers", s, Registers)
+	}
+	return c, nil
+}
+
+func (c Condition) String() string {
+	if c.ValueIsRegister {
+		return fmt.Sprintf("r%d %s r%d", c.Register, c.Operator, c.Value)
+	}
+	return fmt.Sprintf("r%d %s %d", c.Register, c.Operator, c.Value)
+}
+
+// Holds evaluates the condition; registers that do not exist read as 0
+func (c Condition) Holds(registers []int) bool {
+	get := func(i int) int {
+		if i < 0 || i >= len(registers) {
+			return 0
+		}
+		return registers[i]
+	}
+	x, y := get(c.Register), c.Value
+	if c.ValueIsRegister {
+		y = get(c.Value)
+	}
+	switch c.Operator {
+	case "==":
+		return x == y
+	case "!=":
+		return x != y
+	case "<":
+		return x < y
+	case "<=":
+		return x <= y
+	case ">":
+		return x > y
+	case ">=":
+		return x >= y
+	}
+	return false
+}
+
+// Debugger runs a VM one instruction at a time. It stops at breakpoints,
+// which can be conditional, and when a watched register changes.
+// The last executed instructions are kept in a trace
+type Debugger struct {
+	VM *VM
+	// Steps counts the instructions executed so far
+	Steps int
+
+	breakpoints map[int]*Condition
+	watches     map[int]bool
+	trace       []Executed
+	traceLen    int
+	traceStart  int
+}
+
+// NewDebugger returns a debugger that keeps a trace of at most traceLen instructions
+func NewDebugger(vm *VM, traceLen int) *Debugger {
+	return &Debugger{
+		VM:          vm,
+		breakpoints: map[int]*Condition{},
+		watches:     map[int]bool{},
+		traceLen:    traceLen,
+	}
+}
+
+// Break sets a breakpoint on instruction ip, replacing any existing one there.
+// If cond is not nil the debugger only stops when it holds
+func (d *Debugger) Break(ip int, cond *Condition) {
+	d.breakpoints[ip] = cond
+}
+
+// Clear removes the breakpoint on instruction ip
+func (d *Debugger) Clear(ip int) {
+	delete(d.breakpoints, ip)
+}
+
+// Watch makes the debugger stop whenever register reg changes
+func (d *Debugger) Watch(reg int) {
+	d.watches[reg] = true
+}
+
+// Unwatch stops watching register reg
+func (d *Debugger) Unwatch(reg int) {
+	delete(d.watches, reg)
+}
+
+// Step executes a single instruction and records it in the trace.
+// It should not be called on a halted VM
+func (d *Debugger) Step() Executed {
+	vm := d.VM
+	e := Executed{
+		IP:          vm.IP,
+		Instruction: vm.Program.Instructions[vm.IP],
+		Before:      append([]int{}, vm.Registers...),
+	}
+	vm.Step()
+	e.After = append([]int{}, vm.Registers...)
+	d.Steps++
+	d.record(e)
+	return e
+}
+
+func (d *Debugger) record(e Executed) {
+	if d.traceLen <= 0 {
+		return
+	}
+	if len(d.trace) < d.traceLen {
+		d.trace = append(d.trace, e)
+		return
+	}
+	d.trace[d.traceStart] = e
+	d.traceStart = (d.traceStart + 1) % d.traceLen
+}
+
+// Trace returns the last executed instructions, oldest first
+func (d *Debugger) Trace() []Executed {
+	return append(append([]Executed{}, d.trace[d.traceStart:]...), d.trace[:d.traceStart]...)
+}
+
+// Continue executes at least one instruction and keeps going until the VM
+// halts, a watched register changes or the next instruction has a
+// breakpoint whose condition holds. It returns why it stopped
+func (d *Debugger) Continue() string {
+	for !d.VM.Halted() {
+		e := d.Step()
+		for reg := range e.Before {
+			if d.watches[reg] && e.Before[reg] != e.After[reg] {
+				return fmt.Sprintf("r%d changed from %d to %d at %d", reg, e.Before[reg], e.After[reg], e.IP)
+			}
+		}
+		if d.VM.Halted() {
+			break
+		}
+		cond, ok := d.breakpoints[d.VM.IP]
+		if !ok {
+			continue
+		}
+		if cond == nil {
+			return fmt.Sprintf("breakpoint at %d", d.VM.IP)
+		}
+		// the bound register only holds the instruction pointer once it runs
+		registers := append([]int{}, d.VM.Registers...)
+		if bound := d.VM.Program.IPRegister; bound >= 0 {
+			registers[bound] = d.VM.IP
+		}
+		if cond.Holds(registers) {
+			return fmt.Sprintf("breakpoint at %d: %s", d.VM.IP, cond)
+		}
+	}
+	return "halted"
+}
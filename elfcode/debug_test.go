@@ -0,0 +1,121 @@
+package elfcode
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// counts r1 up to 5 in a loop, then sets r2
+const countdown = `#ip 3
+seti 0 0 2
+addi 1 1 1
+gtri 1 4 0
+addr 3 0 3
+seti 0 0 3
+seti 9 0 2`
+
+func TestDebugger(t *testing.T) {
+	p, err := Parse(countdown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := NewDebugger(New(p, 4), 3)
+
+	d.Break(1, nil)
+	if got := d.Continue(); got != "breakpoint at 1" {
+		t.Errorf("got %q", got)
+	}
+	if d.VM.Registers[1] != 0 {
+		t.Errorf("got r1=%d want 0", d.VM.Registers[1])
+	}
+
+	d.Clear(1)
+	cond, err := ParseCondition("r1 >= 4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Break(2, &cond)
+	if got := d.Continue(); got != "breakpoint at 2: r1 >= 4" {
+		t.Errorf("got %q", got)
+	}
+	if d.VM.Registers[1] != 4 {
+		t.Errorf("got r1=%d want 4", d.VM.Registers[1])
+	}
+
+	d.Clear(2)
+	d.Watch(2)
+	if got := d.Continue(); got != "r2 changed from 0 to 9 at 5" {
+		t.Errorf("got %q", got)
+	}
+	if got := d.Continue(); got != "halted" {
+		t.Errorf("got %q", got)
+	}
+
+	ips := []int{}
+	for _, e := range d.Trace() {
+		ips = append(ips, e.IP)
+	}
+	if want := []int{2, 3, 5}; !reflect.DeepEqual(ips, want) {
+		t.Errorf("got trace %v want %v", ips, want)
+	}
+}
+
+func TestParseCondition(t *testing.T) {
+	for _, tt := range []struct {
+		s    string
+		want Condition
+		err  bool
+	}{
+		{s: "r3 == 10", want: Condition{Register: 3, Operator: "==", Value: 10}},
+		{s: "r0<-1", want: Condition{Register: 0, Operator: "<", Value: -1}},
+		{s: "r1 != r2", want: Condition{Register: 1, Operator: "!=", Value: 2, ValueIsRegister: true}},
+		{s: "r1 = 2", err: true},
+		{s: "3 == r1", err: true},
+		{s: "r99999999999999999999 == 1", err: true},
+		{s: "r1 == 99999999999999999999", err: true},
+		{s: "r6 == 1", err: true},
+		{s: "r1 < r6", err: true},
+		{s: "r1 < r-1", err: true},
+	} {
+		got, err := ParseCondition(tt.s)
+		if (err != nil) != tt.err {
+			t.Errorf("%q: got error %v", tt.s, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q: got %+v want %+v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestREPL(t *testing.T) {
+	p, err := Parse(countdown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := NewDebugger(New(p, 4), 10)
+	in := strings.NewReader("b 3 if r0 == 1\nc\nr\ns\n\nfrobnicate\nq\nr\n")
+	var out bytes.Buffer
+	if err := d.REPL(in, &out); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"breakpoint at 3: addr 3 0 3",
+		"breakpoint at 3: r0 == 1",
+		"ip=3 steps=19 r0=1 r1=5 r2=0 r3=2",
+		"  3: addr 3 0 3",
+		"  5: seti 9 0 2",
+		"halted",
+		`unknown command "frobnicate"`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output does not contain %q:\n%s", want, out.String())
+		}
+	}
+	// the empty line repeats the step before it
+	if d.Steps != 21 {
+		t.Errorf("got %d steps want 21", d.Steps)
+	}
+}
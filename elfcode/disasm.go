@@ -0,0 +1,218 @@
+package elfcode
+
+import (
+	"fmt"
+	"strings"
+)
+
+// syntax describes how an opcode reads its operands: r for a register,
+// i for an immediate value and - if it is not used
+var syntax = map[string]struct {
+	operator string
+	a, b     byte
+}{
+	"addr": {"+", 'r', 'r'}, "addi": {"+", 'r', 'i'},
+	"mulr": {"*", 'r', 'r'}, "muli": {"*", 'r', 'i'},
+	"banr": {"&", 'r', 'r'}, "bani": {"&", 'r', 'i'},
+	"borr": {"|", 'r', 'r'}, "bori": {"|", 'r', 'i'},
+	"setr": {"", 'r', '-'}, "seti": {"", 'i', '-'},
+	"gtir": {">", 'i', 'r'}, "gtri": {">", 'r', 'i'}, "gtrr": {">", 'r', 'r'},
+	"eqir": {"==", 'i', 'r'}, "eqri": {"==", 'r', 'i'}, "eqrr": {"==", 'r', 'r'},
+	"divr": {"/", 'r', 'r'}, "divi": {"/", 'r', 'i'},
+	"modr": {"%", 'r', 'r'}, "modi": {"%", 'r', 'i'},
+	"shlr": {"<<", 'r', 'r'}, "shli": {"<<", 'r', 'i'},
+	"shrr": {">>", 'r', 'r'}, "shri": {">>", 'r', 'i'},
+}
+
+func isComparison(op Op) bool {
+	s, ok := syntax[op.Name]
+	return ok && (s.operator == ">" || s.operator == "==")
+}
+
+// expr is the value an instruction writes, as text and, if it does not
+// depend on any register, as a number
+type expr struct {
+	text   string
+	static bool
+	value  int
+}
+
+// express renders the value instruction i writes. The bound register
+// always holds the line number, so reading it is a constant
+func express(ins Instruction, line, ipRegister int) expr {
+	s, ok := syntax[ins.Op.Name]
+	if !ok {
+		return expr{text: fmt.Sprintf("%s(%d, %d)", ins.Op.Name, ins.A, ins.B)}
+	}
+	operand := func(kind byte, x int) (string, bool, int) {
+		if kind == 'i' {
+			return fmt.Sprint(x), true, x
+		}
+		if x == ipRegister {
+			return fmt.Sprint(line), true, line
+		}
+		return fmt.Sprintf("r%d", x), false, 0
+	}
+	a, aStatic, aValue := operand(s.a, ins.A)
+	if s.b == '-' {
+		return expr{text: a, static: aStatic, value: aValue}
+	}
+	b, bStatic, bValue := operand(s.b, ins.B)
+	e := expr{text: fmt.Sprintf("%s %s %s", a, s.operator, b), static: aStatic && bStatic}
+	if e.static {
+		// registers are read from two scratch registers instead
+		registers, x, y := []int{aValue, bValue}, aValue, bValue
+		if s.a == 'r' {
+			x = 0
+		}
+		if s.b == 'r' {
+			y = 1
+		}
+		e.value, e.static = evaluate(ins.Op, registers, x, y)
+	}
+	return e
+}
+
+// evaluate applies op, failing instead of panicking on division by zero
+func evaluate(op Op, registers []int, a, b int) (value int, ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	return op.Do(registers, a, b), true
+}
+
+// disasmLine is one line of pseudocode, standing in for one or two instructions
+type disasmLine struct {
+	code string
+	// folded is set on an instruction that is part of the line before it
+	folded bool
+	// targets are the static jump targets, next is false if control never falls through
+	targets []int
+	next    bool
+	// indirect is set for jumps to a target only known at runtime
+	indirect bool
+}
+
+// Disassemble prints the program as pseudocode, one line per instruction.
+// Reads of the bound register are resolved to the line number, jumps to a
+// known line become gotos to labels and a comparison followed by a jump over
+// the next instruction is folded into a conditional jump. The register
+// written by such a comparison is assumed to be a temporary.
+// Instructions that can never be reached from the first one are marked dead,
+// unless the program has a jump whose target is only known at runtime
+func Disassemble(p Program) string {
+	n := len(p.Instructions)
+	ip := p.IPRegister
+	lines := make([]disasmLine, n)
+	for i, ins := range p.Instructions {
+		e := express(ins, i, ip)
+		if ins.C != ip {
+			lines[i] = disasmLine{code: fmt.Sprintf("r%d = %s", ins.C, e.text), next: true}
+			continue
+		}
+		if e.static {
+			lines[i] = disasmLine{code: "goto " + label(e.value+1, n), targets: []int{e.value + 1}}
+			continue
+		}
+		if i > 0 && skipsOnComparison(p, i) {
+			target := i + 2
+			lines[i-1] = disasmLine{
+				code:    fmt.Sprintf("if %s goto %s", express(p.Instructions[i-1], i-1, ip).text, label(target, n)),
+				targets: []int{target},
+				next:    true,
+			}
+			lines[i] = disasmLine{folded: true, next: true}
+			continue
+		}
+		lines[i] = disasmLine{code: fmt.Sprintf("goto %s + 1", e.text), indirect: true}
+	}
+
+	labels := map[int]bool{}
+	indirect := false
+	for _, l := range lines {
+		for _, t := range l.targets {
+			labels[t] = true
+		}
+		indirect = indirect || l.indirect
+	}
+	reachable := make([]bool, n)
+	if indirect {
+		for i := range reachable {
+			reachable[i] = true
+		}
+	} else {
+		queue := []int{0}
+		for len(queue) > 0 {
+			i := queue[0]
+			queue = queue[1:]
+			if i < 0 || i >= n || reachable[i] {
+				continue
+			}
+			reachable[i] = true
+			if lines[i].next {
+				queue = append(queue, i+1)
+			}
+			queue = append(queue, lines[i].targets...)
+		}
+	}
+
+	width := 0
+	for _, l := range lines {
+		if len(l.code) > width {
+			width = len(l.code)
+		}
+	}
+	var sb strings.Builder
+	if ip >= 0 {
+		fmt.Fprintf(&sb, "#ip %d\n", ip)
+	}
+	for i, l := range lines {
+		if l.folded {
+			continue
+		}
+		if labels[i] {
+			fmt.Fprintf(&sb, "L%d:\n", i)
+		}
+		comment := fmt.Sprintf("%d: %s", i, p.Instructions[i])
+		if i+1 < n && lines[i+1].folded {
+			comment += fmt.Sprintf(", %d: %s", i+1, p.Instructions[i+1])
+		}
+		if !reachable[i] {
+			comment += " (dead)"
+		}
+		fmt.Fprintf(&sb, "\t%-*s  // %s\n", width, l.code, comment)
+	}
+	return sb.String()
+}
+
+// skipsOnComparison returns true if instruction i adds the result of the
+// comparison right before it to the instruction pointer, and nothing
+// jumps to it directly so that result is always 0 or 1
+func skipsOnComparison(p Program, i int) bool {
+	ins, prev := p.Instructions[i], p.Instructions[i-1]
+	ip := p.IPRegister
+	if ins.Op.Name != Addr.Name || !isComparison(prev.Op) || prev.C == ip {
+		return false
+	}
+	if !(ins.A == ip && ins.B == prev.C) && !(ins.B == ip && ins.A == prev.C) {
+		return false
+	}
+	for j, other := range p.Instructions {
+		if other.C != ip {
+			continue
+		}
+		if e := express(other, j, ip); e.static && e.value+1 == i {
+			return false
+		}
+	}
+	return true
+}
+
+func label(target, n int) string {
+	if target < 0 || target >= n {
+		return "halt"
+	}
+	return fmt.Sprintf("L%d", target)
+}
@@ -0,0 +1,63 @@
+package elfcode
+
+import "testing"
+
+func TestDisassemble(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "countdown",
+			input: countdown,
+			want: `#ip 3
+	r2 = 0             // 0: seti 0 0 2
+L1:
+	r1 = r1 + 1        // 1: addi 1 1 1
+	if r1 > 4 goto L5  // 2: gtri 1 4 0, 3: addr 3 0 3
+	goto L1            // 4: seti 0 0 3
+L5:
+	r2 = 9             // 5: seti 9 0 2
+`,
+		},
+		{
+			name: "dead code",
+			input: `#ip 2
+addi 2 1 2
+seti 7 0 0
+mulr 2 2 2`,
+			want: `#ip 2
+	goto L2    // 0: addi 2 1 2
+	r0 = 7     // 1: seti 7 0 0 (dead)
+L2:
+	goto halt  // 2: mulr 2 2 2
+`,
+		},
+		{
+			name: "indirect jump",
+			input: `#ip 1
+addr 0 1 1
+seti 7 0 0`,
+			want: `#ip 1
+	goto r0 + 0 + 1  // 0: addr 0 1 1
+	r0 = 7           // 1: seti 7 0 0
+`,
+		},
+		{
+			name:  "no instruction pointer",
+			input: "eqri 0 3 1\naddr 1 0 0",
+			want: `	r1 = r0 == 3  // 0: eqri 0 3 1
+	r0 = r1 + r0  // 1: addr 1 0 0
+`,
+		},
+	} {
+		p, err := Parse(tt.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := Disassemble(p); got != tt.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// Package elfcode implements the wrist device from days 16, 19 and 21:
+// the sixteen opcodes and a virtual machine to run them on.
+package elfcode
+
+import "fmt"
+
+// Registers is the number of registers of the device in days 19 and 21.
+// Parse and Decode reject programs that use a register beyond it
+const Registers = 6
+
+// Op is an elfcode opcode. Do returns the value that the
+// instruction writes to register c
+type Op struct {
+	Name string
+	Do   func(r []int, a, b int) int
+}
+
+func (o Op) String() string {
+	return o.Name
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+var (
+	Addr = Op{"addr", func(r []int, a, b int) int { return r[a] + r[b] }}
+	Addi = Op{"addi", func(r []int, a, b int) int { return r[a] + b }}
+	Mulr = Op{"mulr", func(r []int, a, b int) int { return r[a] * r[b] }}
+	Muli = Op{"muli", func(r []int, a, b int) int { return r[a] * b }}
+	Banr = Op{"banr", func(r []int, a, b int) int { return r[a] & r[b] }}
+	Bani = Op{"bani", func(r []int, a, b int) int { return r[a] & b }}
+	Borr = Op{"borr", func(r []int, a, b int) int { return r[a] | r[b] }}
+	Bori = Op{"bori", func(r []int, a, b int) int { return r[a] | b }}
+	Setr = Op{"setr", func(r []int, a, b int) int { return r[a] }}
+	Seti = Op{"seti", func(r []int, a, b int) int { return a }}
+	Gtir = Op{"gtir", func(r []int, a, b int) int { return boolToInt(a > r[b]) }}
+	Gtri = Op{"gtri", func(r []int, a, b int) int { return boolToInt(r[a] > b) }}
+	Gtrr = Op{"gtrr", func(r []int, a, b int) int { return boolToInt(r[a] > r[b]) }}
+	Eqir = Op{"eqir", func(r []int, a, b int) int { return boolToInt(a == r[b]) }}
+	Eqri = Op{"eqri", func(r []int, a, b int) int { return boolToInt(r[a] == b) }}
+	Eqrr = Op{"eqrr", func(r []int, a, b int) int { return boolToInt(r[a] == r[b]) }}
+)
+
+// Ops lists all sixteen opcodes in the order of the puzzle description
+var Ops = []Op{Addr, Addi, Mulr, Muli, Banr, Bani, Borr, Bori,
+	Setr, Seti, Gtir, Gtri, Gtrr, Eqir, Eqri, Eqrr}
+
+// Opcodes beyond the puzzle, for analysing samples of other instruction sets.
+// They are not known to Lookup, so only code that asks for them gets them.
+// Like reading a register that does not exist, dividing by zero
+// or shifting by a negative amount panics
+var (
+	Divr = Op{"divr", func(r []int, a, b int) int { return r[a] / r[b] }}
+	Divi = Op{"divi", func(r []int, a, b int) int { return r[a] / b }}
+	Modr = Op{"modr", func(r []int, a, b int) int { return r[a] % r[b] }}
+	Modi = Op{"modi", func(r []int, a, b int) int { return r[a] % b }}
+	Shlr = Op{"shlr", func(r []int, a, b int) int { return r[a] << r[b] }}
+	Shli = Op{"shli", func(r []int, a, b int) int { return r[a] << b }}
+	Shrr = Op{"shrr", func(r []int, a, b int) int { return r[a] >> r[b] }}
+	Shri = Op{"shri", func(r []int, a, b int) int { return r[a] >> b }}
+)
+
+// Extended lists the sixteen puzzle opcodes followed by the extra ones
+var Extended = append(append([]Op{}, Ops...), Divr, Divi, Modr, Modi, Shlr, Shli, Shrr, Shri)
+
+var byName = map[string]Op{}
+
+func init() {
+	for _, op := range Ops {
+		byName[op.Name] = op
+	}
+}
+
+// Lookup finds one of the sixteen puzzle opcodes by its mnemonic
+func Lookup(name string) (Op, bool) {
+	op, ok := byName[name]
+	return op, ok
+}
+
+type Instruction struct {
+	Op      Op
+	A, B, C int
+}
+
+func (i Instruction) String() string {
+	return fmt.Sprintf("%s %d %d %d", i.Op.Name, i.A, i.B, i.C)
+}
+
+// Check returns an error if the instruction reads or writes a register
+// outside of the given number of registers. For an op Check does not know
+// only c can be checked, since a and b might be values
+func (i Instruction) Check(registers int) error {
+	check := func(name string, x int) error {
+		if x < 0 || x >= registers {
+			return fmt.Errorf("register %s=%d out of range, there are %d registers", name, x, registers)
+		}
+		return nil
+	}
+	if s, ok := syntax[i.Op.Name]; ok {
+		if s.a == 'r' {
+			if err := check("a", i.A); err != nil {
+				return err
+			}
+		}
+		if s.b == 'r' {
+			if err := check("b", i.B); err != nil {
+				return err
+			}
+		}
+	}
+	return check("c", i.C)
+}
+
+// Execute applies the instruction to the registers in place
+func (i Instruction) Execute(r []int) {
+	r[i.C] = i.Op.Do(r, i.A, i.B)
+}
@@ -0,0 +1,100 @@
+package elfcode
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOps(t *testing.T) {
+	// example from day 16: opcode 9 2 1 2 behaves like mulr, addi and seti
+	before := []int{3, 2, 1, 1}
+	after := []int{3, 2, 2, 1}
+	for _, tt := range []struct {
+		ops  []Op
+		want []string
+	}{
+		{Ops, []string{"addi", "mulr", "seti"}},
+		// 1 << 1 is 2 as well
+		{Extended, []string{"addi", "mulr", "seti", "shli"}},
+	} {
+		got := []string{}
+		for _, op := range tt.ops {
+			r := append([]int{}, before...)
+			Instruction{op, 2, 1, 2}.Execute(r)
+			if reflect.DeepEqual(r, after) {
+				got = append(got, op.Name)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("got %v want %v", got, tt.want)
+		}
+	}
+	if op, ok := Lookup("modi"); ok {
+		t.Errorf("modi: got %v from Lookup, want only the puzzle ops", op)
+	}
+	if op := Modi; op.Do([]int{7}, 0, 4) != 3 {
+		t.Errorf("modi: got %d want 3", op.Do([]int{7}, 0, 4))
+	}
+}
+
+func TestRun(t *testing.T) {
+	input := `#ip 0
+			seti 5 0 1
+			seti 6 0 2
+			addi 0 1 0
+			addr 1 2 3
+			setr 1 0 0
+			seti 8 0 4
+			seti 9 0 5`
+	p, err := Parse(strings.Replace(input, "\t", "", -1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.IPRegister != 0 || len(p.Instructions) != 7 {
+		t.Fatalf("got ip %d and %d instructions", p.IPRegister, len(p.Instructions))
+	}
+	vm := New(p, 6)
+	vm.Run()
+	want := []int{6, 5, 6, 0, 0, 9}
+	if !reflect.DeepEqual(vm.Registers, want) {
+		t.Errorf("got %v want %v", vm.Registers, want)
+	}
+}
+
+func TestUnboundIP(t *testing.T) {
+	p, err := Parse("seti 7 0 0\naddi 0 1 1\nmulr 1 1 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	vm := New(p, 4)
+	vm.Run()
+	want := []int{7, 8, 64, 0}
+	if !reflect.DeepEqual(vm.Registers, want) {
+		t.Errorf("got %v want %v", vm.Registers, want)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	for i, tt := range []struct {
+		input string
+		want  string
+	}{
+		{"#ip 0\nfoo 1 2 3", `line 2: cannot parse "foo 1 2 3" as "%s %d %d %d": unknown opcode "foo"`},
+		{"seti 1 2 3\n#ip 0", `line 2: cannot parse "#ip 0" as "#ip n": #ip should be on the first line`},
+		{"#ip 5\naddr 9 0 1", `line 2: cannot parse "addr 9 0 1" as "%s %d %d %d": register a=9 out of range, there are 6 registers`},
+		{"#ip 5\nseti 9 0 6", `line 2: cannot parse "seti 9 0 6" as "%s %d %d %d": register c=6 out of range, there are 6 registers`},
+		{"#ip 6\nseti 9 0 1", `line 1: cannot parse "#ip 6" as "#ip %d": register 6 out of range, there are 6 registers`},
+		// extended ops are only known when asked for
+		{"#ip 5\ndivi 1 0 1", `line 2: cannot parse "divi 1 0 1" as "%s %d %d %d": unknown opcode "divi"`},
+	} {
+		_, err := Parse(tt.input)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("%d): got %v want %s", i, err, tt.want)
+		}
+	}
+	p, err := ParseWith("#ip 5\ndivi 1 0 1", Extended)
+	if err != nil || p.Instructions[0].Op.Name != "divi" {
+		t.Errorf("extended: got %v, %v", p, err)
+	}
+}
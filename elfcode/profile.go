@@ -0,0 +1,182 @@
+package elfcode
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Profile records what a program did while it ran: how often each
+// instruction was executed, which registers it wrote and which
+// control flow edges were taken
+type Profile struct {
+	Program Program
+	Steps   int
+	Halted  bool
+	// Counts is the number of times each instruction was executed
+	Counts []int
+	// Writes counts per instruction how often it wrote each register
+	Writes [][]int
+	// Edges counts the jumps from one instruction to the next.
+	// An edge to an instruction outside the program halts it
+	Edges map[Edge]int
+}
+
+// Edge is a transfer of control from one instruction to another
+type Edge struct {
+	From, To int
+}
+
+// Back returns true if the edge jumps back, closing a loop
+func (e Edge) Back() bool {
+	return e.To >= 0 && e.To <= e.From
+}
+
+// Loop is a back-edge together with the number of times it was taken
+type Loop struct {
+	Edge
+	Trips int
+}
+
+// Record profiles the VM until it halts or has executed maxSteps instructions
+func Record(vm *VM, maxSteps int) *Profile {
+	n := len(vm.Program.Instructions)
+	p := &Profile{
+		Program: vm.Program,
+		Counts:  make([]int, n),
+		Writes:  make([][]int, n),
+		Edges:   map[Edge]int{},
+	}
+	for i := range p.Writes {
+		p.Writes[i] = make([]int, len(vm.Registers))
+	}
+	for p.Steps < maxSteps && !vm.Halted() {
+		ip := vm.IP
+		vm.Step()
+		p.Steps++
+		p.Counts[ip]++
+		p.Writes[ip][vm.Program.Instructions[ip].C]++
+		p.Edges[Edge{ip, vm.IP}]++
+	}
+	p.Halted = vm.Halted()
+	return p
+}
+
+// Loops returns the back-edges that were taken, most taken first
+func (p *Profile) Loops() []Loop {
+	loops := []Loop{}
+	for e, n := range p.Edges {
+		if e.Back() {
+			loops = append(loops, Loop{e, n})
+		}
+	}
+	sort.Slice(loops, func(i, j int) bool {
+		if loops[i].Trips != loops[j].Trips {
+			return loops[i].Trips > loops[j].Trips
+		}
+		return loops[i].From < loops[j].From
+	})
+	return loops
+}
+
+// heat shades a count relative to the maximum
+const heat = " .:-=+*#%@"
+
+func shade(n, max int) byte {
+	if n == 0 || max == 0 {
+		return heat[0]
+	}
+	return heat[1+n*(len(heat)-2)/max]
+}
+
+// Listing prints the program annotated with execution counts and their
+// share of all steps, a heatmap of register writes with one column per
+// register and the back-edges that end on each instruction
+func (p *Profile) Listing(w io.Writer) {
+	maxWrites := 0
+	for _, writes := range p.Writes {
+		for _, n := range writes {
+			if n > maxWrites {
+				maxWrites = n
+			}
+		}
+	}
+	loops := map[int][]Loop{}
+	for _, l := range p.Loops() {
+		loops[l.To] = append(loops[l.To], l)
+	}
+	width := len("instruction")
+	for _, ins := range p.Program.Instructions {
+		if len(ins.String()) > width {
+			width = len(ins.String())
+		}
+	}
+	registers := ""
+	if len(p.Writes) > 0 {
+		for i := range p.Writes[0] {
+			registers += fmt.Sprint(i)
+		}
+	}
+	status := "halted"
+	if !p.Halted {
+		status = "stopped"
+	}
+	fmt.Fprintf(w, "%s after %d steps\n", status, p.Steps)
+	fmt.Fprintf(w, "%4s %12s %7s  %-*s  %s\n", "ip", "count", "%", width, "instruction", registers)
+	for i, ins := range p.Program.Instructions {
+		heatmap := make([]byte, len(p.Writes[i]))
+		for r, n := range p.Writes[i] {
+			heatmap[r] = shade(n, maxWrites)
+		}
+		pct := 0.0
+		if p.Steps > 0 {
+			pct = 100 * float64(p.Counts[i]) / float64(p.Steps)
+		}
+		line := fmt.Sprintf("%4d %12d %6.2f%%  %-*s  %s", i, p.Counts[i], pct, width, ins, heatmap)
+		for _, l := range loops[i] {
+			line += fmt.Sprintf("  <- %d (%d trips)", l.From, l.Trips)
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// DOT writes the control flow graph as it was taken in Graphviz format.
+// Edges are labeled with the number of times they were taken,
+// back-edges are drawn in red
+func (p *Profile) DOT(w io.Writer) {
+	fmt.Fprintln(w, "digraph elfcode {")
+	fmt.Fprintln(w, "\tnode [shape=box fontname=monospace];")
+	for i, ins := range p.Program.Instructions {
+		fmt.Fprintf(w, "\tn%d [label=\"%d: %s\\n%d\"];\n", i, i, ins, p.Counts[i])
+	}
+	edges := make([]Edge, 0, len(p.Edges))
+	halts := false
+	for e := range p.Edges {
+		edges = append(edges, e)
+		if e.To < 0 || e.To >= len(p.Program.Instructions) {
+			halts = true
+		}
+	}
+	if halts {
+		fmt.Fprintln(w, "\thalt [shape=doublecircle];")
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		return edges[i].To < edges[j].To
+	})
+	for _, e := range edges {
+		to := fmt.Sprintf("n%d", e.To)
+		if e.To < 0 || e.To >= len(p.Program.Instructions) {
+			to = "halt"
+		}
+		attrs := []string{fmt.Sprintf("label=%d", p.Edges[e])}
+		if e.Back() {
+			attrs = append(attrs, "color=red")
+		}
+		fmt.Fprintf(w, "\tn%d -> %s [%s];\n", e.From, to, strings.Join(attrs, " "))
+	}
+	fmt.Fprintln(w, "}")
+}
@@ -0,0 +1,65 @@
+package elfcode
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRecord(t *testing.T) {
+	p, err := Parse(countdown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prof := Record(New(p, 4), 100)
+	if !prof.Halted || prof.Steps != 21 {
+		t.Fatalf("got halted %t after %d steps", prof.Halted, prof.Steps)
+	}
+	if want := []int{1, 5, 5, 5, 4, 1}; !reflect.DeepEqual(prof.Counts, want) {
+		t.Errorf("got counts %v want %v", prof.Counts, want)
+	}
+	// addr 3 0 3 only jumps, writing the pointer register
+	if want := []int{0, 0, 0, 5}; !reflect.DeepEqual(prof.Writes[3], want) {
+		t.Errorf("got writes %v want %v", prof.Writes[3], want)
+	}
+	if want := []Loop{{Edge{4, 1}, 4}}; !reflect.DeepEqual(prof.Loops(), want) {
+		t.Errorf("got loops %v want %v", prof.Loops(), want)
+	}
+
+	var listing bytes.Buffer
+	prof.Listing(&listing)
+	for _, want := range []string{
+		"halted after 21 steps",
+		"   1            5  23.81%  addi 1 1 1    @    <- 4 (4 trips)",
+		"   4            4  19.05%  seti 0 0 3      #",
+	} {
+		if !strings.Contains(listing.String(), want) {
+			t.Errorf("listing does not contain %q:\n%s", want, listing.String())
+		}
+	}
+
+	var dot bytes.Buffer
+	prof.DOT(&dot)
+	for _, want := range []string{
+		`n1 [label="1: addi 1 1 1\n5"];`,
+		"n4 -> n1 [label=4 color=red];",
+		"n5 -> halt [label=1];",
+	} {
+		if !strings.Contains(dot.String(), want) {
+			t.Errorf("DOT does not contain %q:\n%s", want, dot.String())
+		}
+	}
+}
+
+func TestRecordStops(t *testing.T) {
+	// jumps back to itself forever
+	p, err := Parse("#ip 0\nseti -1 0 0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	prof := Record(New(p, 1), 10)
+	if prof.Halted || prof.Steps != 10 {
+		t.Errorf("got halted %t after %d steps", prof.Halted, prof.Steps)
+	}
+}
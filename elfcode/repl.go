@@ -0,0 +1,208 @@
+package elfcode
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const replHelp = `commands:
+	break n [if cond]  stop before instruction n, optionally only if cond holds (b)
+	delete n           remove the breakpoint on instruction n (d)
+	watch rX           stop whenever register X changes (w)
+	unwatch rX         stop watching register X
+	step [n]           execute n instructions, default 1 (s)
+	continue           run until a breakpoint, a watch or the program halts (c)
+	regs               dump the instruction pointer and registers (r)
+	trace              print the last executed instructions (t)
+	list               print the instructions around the instruction pointer (l)
+	info               print breakpoints and watches (i)
+	help               print this help (h)
+	quit               stop debugging (q)
+conditions look like r3 == 10 or r1 > r2, using == != < <= > >=
+`
+
+// REPL reads debugger commands from in, one per line, until quit or EOF.
+// An empty line repeats the previous command
+func (d *Debugger) REPL(in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	var last string
+	fmt.Fprint(out, "(elfcode) ")
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			line = last
+		}
+		last = line
+		quit, err := d.command(line, out)
+		if err != nil {
+			fmt.Fprintln(out, err)
+		}
+		if quit {
+			return nil
+		}
+		fmt.Fprint(out, "(elfcode) ")
+	}
+	return scanner.Err()
+}
+
+func (d *Debugger) command(line string, out io.Writer) (bool, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return false, nil
+	}
+	args := fields[1:]
+	switch fields[0] {
+	case "break", "b":
+		if len(args) == 0 {
+			return false, fmt.Errorf("usage: break n [if cond]")
+		}
+		ip, err := d.instructionIndex(args[0])
+		if err != nil {
+			return false, err
+		}
+		var cond *Condition
+		if len(args) > 1 {
+			if args[1] != "if" {
+				return false, fmt.Errorf("usage: break n [if cond]")
+			}
+			c, err := ParseCondition(strings.Join(args[2:], " "))
+			if err != nil {
+				return false, err
+			}
+			cond = &c
+		}
+		d.Break(ip, cond)
+		fmt.Fprintf(out, "breakpoint at %d: %s\n", ip, d.VM.Program.Instructions[ip])
+	case "delete", "d":
+		if len(args) != 1 {
+			return false, fmt.Errorf("usage: delete n")
+		}
+		ip, err := d.instructionIndex(args[0])
+		if err != nil {
+			return false, err
+		}
+		d.Clear(ip)
+	case "watch", "w", "unwatch":
+		if len(args) != 1 {
+			return false, fmt.Errorf("usage: %s rX", fields[0])
+		}
+		reg, err := d.register(args[0])
+		if err != nil {
+			return false, err
+		}
+		if fields[0] == "unwatch" {
+			d.Unwatch(reg)
+			break
+		}
+		d.Watch(reg)
+	case "step", "s":
+		n := 1
+		if len(args) > 0 {
+			var err error
+			n, err = strconv.Atoi(args[0])
+			if err != nil || n < 1 {
+				return false, fmt.Errorf("invalid number of steps %q", args[0])
+			}
+		}
+		for i := 0; i < n && !d.VM.Halted(); i++ {
+			fmt.Fprintln(out, d.Step())
+		}
+		if d.VM.Halted() {
+			fmt.Fprintln(out, "halted")
+		}
+	case "continue", "c":
+		if d.VM.Halted() {
+			fmt.Fprintln(out, "halted")
+			break
+		}
+		fmt.Fprintln(out, d.Continue())
+		d.printRegisters(out)
+	case "regs", "r":
+		d.printRegisters(out)
+	case "trace", "t":
+		for _, e := range d.Trace() {
+			fmt.Fprintln(out, e)
+		}
+	case "list", "l":
+		d.list(out)
+	case "info", "i":
+		d.info(out)
+	case "help", "h":
+		fmt.Fprint(out, replHelp)
+	case "quit", "q":
+		return true, nil
+	default:
+		return false, fmt.Errorf("unknown command %q, try help", fields[0])
+	}
+	return false, nil
+}
+
+func (d *Debugger) instructionIndex(s string) (int, error) {
+	ip, err := strconv.Atoi(s)
+	if err != nil || ip < 0 || ip >= len(d.VM.Program.Instructions) {
+		return 0, fmt.Errorf("no instruction %q", s)
+	}
+	return ip, nil
+}
+
+func (d *Debugger) register(s string) (int, error) {
+	reg, err := strconv.Atoi(strings.TrimPrefix(s, "r"))
+	if err != nil || reg < 0 || reg >= len(d.VM.Registers) {
+		return 0, fmt.Errorf("no register %q", s)
+	}
+	return reg, nil
+}
+
+func (d *Debugger) printRegisters(out io.Writer) {
+	fmt.Fprintf(out, "ip=%d steps=%d", d.VM.IP, d.Steps)
+	for i, v := range d.VM.Registers {
+		fmt.Fprintf(out, " r%d=%d", i, v)
+	}
+	fmt.Fprintln(out)
+}
+
+// list prints a few instructions around the instruction pointer,
+// marking breakpoints with * and the next instruction with >
+func (d *Debugger) list(out io.Writer) {
+	for i, ins := range d.VM.Program.Instructions {
+		if i < d.VM.IP-5 || i > d.VM.IP+5 {
+			continue
+		}
+		mark := ' '
+		if _, ok := d.breakpoints[i]; ok {
+			mark = '*'
+		}
+		cursor := ' '
+		if i == d.VM.IP {
+			cursor = '>'
+		}
+		fmt.Fprintf(out, "%c%c%3d: %s\n", cursor, mark, i, ins)
+	}
+}
+
+func (d *Debugger) info(out io.Writer) {
+	ips := []int{}
+	for ip := range d.breakpoints {
+		ips = append(ips, ip)
+	}
+	sort.Ints(ips)
+	for _, ip := range ips {
+		if cond := d.breakpoints[ip]; cond != nil {
+			fmt.Fprintf(out, "breakpoint at %d if %s\n", ip, cond)
+			continue
+		}
+		fmt.Fprintf(out, "breakpoint at %d\n", ip)
+	}
+	regs := []int{}
+	for reg := range d.watches {
+		regs = append(regs, reg)
+	}
+	sort.Ints(regs)
+	for _, reg := range regs {
+		fmt.Fprintf(out, "watching r%d\n", reg)
+	}
+}
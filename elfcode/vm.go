@@ -0,0 +1,101 @@
+package elfcode
+
+import (
+	"strings"
+
+	"github.com/deosjr/adventofcode2018/scan"
+)
+
+// Program is a list of instructions, optionally with
+// a register bound to the instruction pointer
+type Program struct {
+	// IPRegister is bound to the instruction pointer, or -1 if there is none
+	IPRegister   int
+	Instructions []Instruction
+}
+
+// Parse reads a program in mnemonic form, starting with an optional #ip line
+func Parse(input string) (Program, error) {
+	return ParseWith(input, Ops)
+}
+
+// ParseWith is Parse for a program that may use any of ops
+func ParseWith(input string, ops []Op) (Program, error) {
+	byName := map[string]Op{}
+	for _, op := range ops {
+		byName[op.Name] = op
+	}
+	p := Program{IPRegister: -1}
+	r := scan.NewReader(input)
+	for r.Scan() {
+		if strings.HasPrefix(r.Text(), "#ip") {
+			if r.Line() != 1 {
+				return Program{}, r.Errorf("#ip n", "#ip should be on the first line")
+			}
+			if err := r.Scanf("#ip %d", &p.IPRegister); err != nil {
+				return Program{}, err
+			}
+			if p.IPRegister < 0 || p.IPRegister >= Registers {
+				return Program{}, r.Errorf("#ip %d", "register %d out of range, there are %d registers", p.IPRegister, Registers)
+			}
+			continue
+		}
+		var name string
+		var i Instruction
+		if err := r.Scanf("%s %d %d %d", &name, &i.A, &i.B, &i.C); err != nil {
+			return Program{}, err
+		}
+		op, ok := byName[name]
+		if !ok {
+			return Program{}, r.Errorf("%s %d %d %d", "unknown opcode %q", name)
+		}
+		i.Op = op
+		if err := i.Check(Registers); err != nil {
+			return Program{}, r.Error("%s %d %d %d", err)
+		}
+		p.Instructions = append(p.Instructions, i)
+	}
+	return p, nil
+}
+
+// VM runs a program. The instruction pointer is kept separately from the
+// registers: if it is bound, its register is written before and read back
+// after every instruction
+type VM struct {
+	Program   Program
+	Registers []int
+	IP        int
+}
+
+// New returns a VM with the given number of registers, all set to 0
+func New(p Program, registers int) *VM {
+	return &VM{
+		Program:   p,
+		Registers: make([]int, registers),
+	}
+}
+
+// Halted returns true if the instruction pointer is outside the program
+func (vm *VM) Halted() bool {
+	return vm.IP < 0 || vm.IP >= len(vm.Program.Instructions)
+}
+
+// Step executes a single instruction. It should not be called on a halted VM
+func (vm *VM) Step() {
+	bound := vm.Program.IPRegister
+	if bound >= 0 {
+		vm.Registers[bound] = vm.IP
+	}
+	vm.Program.Instructions[vm.IP].Execute(vm.Registers)
+	if bound >= 0 {
+		vm.IP = vm.Registers[bound]
+	}
+	vm.IP++
+}
+
+// Run executes instructions until the program halts
+func (vm *VM) Run() {
+	for !vm.Halted() {
+		vm.Step()
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/deosjr/adventofcode2018
+
+go 1.21
@@ -0,0 +1,62 @@
+package grid
+
+// Box is an inclusive bounding box. The zero value is empty
+type Box struct {
+	Min, Max Coord
+	nonEmpty bool
+}
+
+// NewBox returns the box spanning min to max inclusive
+func NewBox(min, max Coord) Box {
+	return Box{Min: min, Max: max, nonEmpty: true}
+}
+
+// Add grows the box to include c
+func (b *Box) Add(c Coord) {
+	if !b.nonEmpty {
+		*b = NewBox(c, c)
+		return
+	}
+	if c.X < b.Min.X {
+		b.Min.X = c.X
+	}
+	if c.X > b.Max.X {
+		b.Max.X = c.X
+	}
+	if c.Y < b.Min.Y {
+		b.Min.Y = c.Y
+	}
+	if c.Y > b.Max.Y {
+		b.Max.Y = c.Y
+	}
+}
+
+func (b Box) Empty() bool {
+	return !b.nonEmpty
+}
+
+func (b Box) Contains(c Coord) bool {
+	return b.nonEmpty && c.X >= b.Min.X && c.X <= b.Max.X && c.Y >= b.Min.Y && c.Y <= b.Max.Y
+}
+
+// OnEdge returns true if c is on the outer border of the box
+func (b Box) OnEdge(c Coord) bool {
+	if !b.Contains(c) {
+		return false
+	}
+	return c.X == b.Min.X || c.X == b.Max.X || c.Y == b.Min.Y || c.Y == b.Max.Y
+}
+
+func (b Box) Width() int {
+	if !b.nonEmpty {
+		return 0
+	}
+	return b.Max.X - b.Min.X + 1
+}
+
+func (b Box) Height() int {
+	if !b.nonEmpty {
+		return 0
+	}
+	return b.Max.Y - b.Min.Y + 1
+}
@@ -0,0 +1,59 @@
+package grid
+
+// Dense is a fixed size grid backed by a slice, with {0,0} in the top left
+type Dense[T any] struct {
+	cells         []T
+	width, height int
+}
+
+func NewDense[T any](width, height int) *Dense[T] {
+	return &Dense[T]{
+		cells:  make([]T, width*height),
+		width:  width,
+		height: height,
+	}
+}
+
+func (g *Dense[T]) Width() int {
+	return g.width
+}
+
+func (g *Dense[T]) Height() int {
+	return g.height
+}
+
+func (g *Dense[T]) Bounds() Box {
+	if g.width == 0 || g.height == 0 {
+		return Box{}
+	}
+	return NewBox(Coord{0, 0}, Coord{g.width - 1, g.height - 1})
+}
+
+// In returns true if c lies within the grid
+func (g *Dense[T]) In(c Coord) bool {
+	return c.X >= 0 && c.X < g.width && c.Y >= 0 && c.Y < g.height
+}
+
+// At returns the value at c, or the zero value if c is out of bounds
+func (g *Dense[T]) At(c Coord) T {
+	if !g.In(c) {
+		var zero T
+		return zero
+	}
+	return g.cells[c.Y*g.width+c.X]
+}
+
+// Set panics if c is out of bounds
+func (g *Dense[T]) Set(c Coord, v T) {
+	if !g.In(c) {
+		panic("grid: Set out of bounds")
+	}
+	g.cells[c.Y*g.width+c.X] = v
+}
+
+// Each calls f for every cell in reading order
+func (g *Dense[T]) Each(f func(Coord, T)) {
+	for i, v := range g.cells {
+		f(Coord{i % g.width, i / g.width}, v)
+	}
+}
@@ -0,0 +1,79 @@
+// Package grid holds the 2D coordinate helpers shared between days:
+// reading order, neighbours, bounding boxes and dense or sparse grids.
+package grid
+
+import "sort"
+
+// Coord is a position on a grid, with y growing downwards
+type Coord struct {
+	X, Y int
+}
+
+// unit steps, in reading order
+var (
+	Up    = Coord{0, -1}
+	Left  = Coord{-1, 0}
+	Right = Coord{1, 0}
+	Down  = Coord{0, 1}
+)
+
+func (c Coord) Add(d Coord) Coord {
+	return Coord{c.X + d.X, c.Y + d.Y}
+}
+
+func (c Coord) Manhattan(d Coord) int {
+	return abs(c.X-d.X) + abs(c.Y-d.Y)
+}
+
+// Less returns true if c comes before d in reading order:
+// top to bottom, then left to right
+func (c Coord) Less(d Coord) bool {
+	if c.Y != d.Y {
+		return c.Y < d.Y
+	}
+	return c.X < d.X
+}
+
+// Neighbours4 returns the orthogonal neighbours of c in reading order
+func (c Coord) Neighbours4() [4]Coord {
+	return [4]Coord{c.Add(Up), c.Add(Left), c.Add(Right), c.Add(Down)}
+}
+
+// Neighbours8 returns the orthogonal and diagonal neighbours of c in reading order
+func (c Coord) Neighbours8() [8]Coord {
+	return [8]Coord{
+		{c.X - 1, c.Y - 1}, {c.X, c.Y - 1}, {c.X + 1, c.Y - 1},
+		{c.X - 1, c.Y}, {c.X + 1, c.Y},
+		{c.X - 1, c.Y + 1}, {c.X, c.Y + 1}, {c.X + 1, c.Y + 1},
+	}
+}
+
+// First returns the coord that comes first in reading order.
+// coords should not be empty
+func First(coords []Coord) Coord {
+	first := coords[0]
+	for _, c := range coords[1:] {
+		if c.Less(first) {
+			first = c
+		}
+	}
+	return first
+}
+
+// Insert adds v to list, which is kept sorted in reading order of pos(v).
+// v goes after any element at the same position
+func Insert[T any](list []T, v T, pos func(T) Coord) []T {
+	p := pos(v)
+	index := sort.Search(len(list), func(i int) bool { return p.Less(pos(list[i])) })
+	list = append(list, v)
+	copy(list[index+1:], list[index:])
+	list[index] = v
+	return list
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
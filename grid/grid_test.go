@@ -0,0 +1,118 @@
+package grid
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLess(t *testing.T) {
+	for i, tt := range []struct {
+		p, q Coord
+		want bool
+	}{
+		{Coord{0, 0}, Coord{1, 0}, true},
+		{Coord{1, 0}, Coord{0, 0}, false},
+		{Coord{5, 0}, Coord{0, 1}, true},
+		{Coord{0, 1}, Coord{5, 0}, false},
+		{Coord{2, 2}, Coord{2, 2}, false},
+	} {
+		if got := tt.p.Less(tt.q); got != tt.want {
+			t.Errorf("%d): got %t want %t", i, got, tt.want)
+		}
+	}
+}
+
+func TestFirst(t *testing.T) {
+	for i, tt := range []struct {
+		coords []Coord
+		want   Coord
+	}{
+		{[]Coord{{0, 0}}, Coord{0, 0}},
+		{[]Coord{{4, 2}, {1, 0}, {2, 3}}, Coord{1, 0}},
+		{[]Coord{{4, 2}, {3, 3}}, Coord{4, 2}},
+	} {
+		if got := First(tt.coords); got != tt.want {
+			t.Errorf("%d): got %v want %v", i, got, tt.want)
+		}
+	}
+}
+
+func TestInsert(t *testing.T) {
+	id := func(c Coord) Coord { return c }
+	list := []Coord{}
+	for _, c := range []Coord{{3, 1}, {0, 2}, {1, 0}, {0, 1}, {2, 0}} {
+		list = Insert(list, c, id)
+	}
+	want := []Coord{{1, 0}, {2, 0}, {0, 1}, {3, 1}, {0, 2}}
+	if !reflect.DeepEqual(list, want) {
+		t.Errorf("got %v want %v", list, want)
+	}
+}
+
+func TestNeighbours(t *testing.T) {
+	c := Coord{1, 1}
+	n4 := c.Neighbours4()
+	if want := [4]Coord{{1, 0}, {0, 1}, {2, 1}, {1, 2}}; n4 != want {
+		t.Errorf("Neighbours4: got %v want %v", n4, want)
+	}
+	n8 := c.Neighbours8()
+	for i := 1; i < len(n8); i++ {
+		if !n8[i-1].Less(n8[i]) {
+			t.Errorf("Neighbours8 not in reading order: %v", n8)
+		}
+		if n8[i].Manhattan(c) > 2 || n8[i] == c {
+			t.Errorf("Neighbours8: %v is not a neighbour of %v", n8[i], c)
+		}
+	}
+}
+
+func TestBox(t *testing.T) {
+	var b Box
+	if !b.Empty() || b.Contains(Coord{0, 0}) || b.Width() != 0 {
+		t.Errorf("zero box should be empty: %+v", b)
+	}
+	for _, c := range []Coord{{3, 4}, {-1, 6}, {2, 2}} {
+		b.Add(c)
+	}
+	if b.Min != (Coord{-1, 2}) || b.Max != (Coord{3, 6}) {
+		t.Errorf("got %v..%v want %v..%v", b.Min, b.Max, Coord{-1, 2}, Coord{3, 6})
+	}
+	if b.Width() != 5 || b.Height() != 5 {
+		t.Errorf("got %dx%d want 5x5", b.Width(), b.Height())
+	}
+	if !b.OnEdge(Coord{-1, 3}) || b.OnEdge(Coord{0, 3}) || b.OnEdge(Coord{-2, 3}) {
+		t.Errorf("OnEdge wrong for %+v", b)
+	}
+}
+
+func TestDense(t *testing.T) {
+	g := NewDense[int](3, 2)
+	g.Set(Coord{2, 1}, 7)
+	if got := g.At(Coord{2, 1}); got != 7 {
+		t.Errorf("got %d want %d", got, 7)
+	}
+	if got := g.At(Coord{3, 1}); got != 0 {
+		t.Errorf("out of bounds: got %d want %d", got, 0)
+	}
+	var visited []Coord
+	g.Each(func(c Coord, _ int) { visited = append(visited, c) })
+	if len(visited) != 6 || visited[5] != (Coord{2, 1}) {
+		t.Errorf("Each visited %v", visited)
+	}
+}
+
+func TestSparse(t *testing.T) {
+	g := NewSparse[string]()
+	g.Set(Coord{-5, 3}, "a")
+	g.Set(Coord{10, -2}, "b")
+	if _, ok := g.Get(Coord{0, 0}); ok {
+		t.Errorf("unset coord should not be found")
+	}
+	if got := g.At(Coord{10, -2}); got != "b" {
+		t.Errorf("got %q want %q", got, "b")
+	}
+	b := g.Bounds()
+	if b.Min != (Coord{-5, -2}) || b.Max != (Coord{10, 3}) || g.Len() != 2 {
+		t.Errorf("got bounds %v..%v len %d", b.Min, b.Max, g.Len())
+	}
+}
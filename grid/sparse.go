@@ -0,0 +1,42 @@
+package grid
+
+// Sparse is an unbounded grid backed by a map.
+// It keeps track of the bounding box of every coord ever set
+type Sparse[T any] struct {
+	cells  map[Coord]T
+	bounds Box
+}
+
+func NewSparse[T any]() *Sparse[T] {
+	return &Sparse[T]{cells: map[Coord]T{}}
+}
+
+// At returns the value at c, or the zero value if c was never set
+func (g *Sparse[T]) At(c Coord) T {
+	return g.cells[c]
+}
+
+func (g *Sparse[T]) Get(c Coord) (T, bool) {
+	v, ok := g.cells[c]
+	return v, ok
+}
+
+func (g *Sparse[T]) Set(c Coord, v T) {
+	g.cells[c] = v
+	g.bounds.Add(c)
+}
+
+func (g *Sparse[T]) Len() int {
+	return len(g.cells)
+}
+
+func (g *Sparse[T]) Bounds() Box {
+	return g.bounds
+}
+
+// Each calls f for every cell that was set, in no particular order
+func (g *Sparse[T]) Each(f func(Coord, T)) {
+	for c, v := range g.cells {
+		f(c, v)
+	}
+}
@@ -0,0 +1,120 @@
+// Package scan reads puzzle input line by line and reports
+// exactly where and why a line could not be parsed.
+package scan
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ParseError reports a line of input that did not match the expected format.
+// File is empty unless filled in by whoever read the input from disk.
+type ParseError struct {
+	File     string
+	Line     int
+	Text     string
+	Expected string
+	Err      error
+}
+
+func (e *ParseError) Error() string {
+	pos := fmt.Sprintf("line %d", e.Line)
+	if e.File != "" {
+		pos = fmt.Sprintf("%s:%d", e.File, e.Line)
+	}
+	return fmt.Sprintf("%s: cannot parse %q as %q: %v", pos, e.Text, e.Expected, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Lines splits input into lines. A single trailing newline is ignored
+// so input files may or may not end with one.
+func Lines(input string) []string {
+	input = strings.TrimSuffix(input, "\n")
+	return strings.Split(input, "\n")
+}
+
+// Sscanf is fmt.Sscanf, but fails unless every argument is filled
+// and nothing but whitespace is left over
+func Sscanf(s, format string, args ...interface{}) error {
+	var rest string
+	n, err := fmt.Sscanf(s, format+"%s", append(args, &rest)...)
+	if n < len(args) {
+		if err == nil || err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	if n > len(args) {
+		return fmt.Errorf("unexpected trailing text %q", rest)
+	}
+	return nil
+}
+
+// Reader walks over the lines of an input, keeping track of line numbers
+type Reader struct {
+	lines []string
+	line  int
+}
+
+func NewReader(input string) *Reader {
+	return &Reader{lines: Lines(input)}
+}
+
+// Scan advances to the next line, returning false at the end of input
+func (r *Reader) Scan() bool {
+	if r.line >= len(r.lines) {
+		r.line = len(r.lines) + 1
+		return false
+	}
+	r.line++
+	return true
+}
+
+// Text returns the current line
+func (r *Reader) Text() string {
+	if r.line < 1 || r.line > len(r.lines) {
+		return ""
+	}
+	return r.lines[r.line-1]
+}
+
+// Line returns the 1-based number of the current line
+func (r *Reader) Line() int {
+	return r.line
+}
+
+// Scanf parses the current line according to format, see Sscanf
+func (r *Reader) Scanf(format string, args ...interface{}) error {
+	return r.Error(format, Sscanf(r.Text(), format, args...))
+}
+
+// Next advances to the next line and parses it according to format.
+// Running out of input is an error.
+func (r *Reader) Next(format string, args ...interface{}) error {
+	if !r.Scan() {
+		return r.Error(format, io.ErrUnexpectedEOF)
+	}
+	return r.Scanf(format, args...)
+}
+
+// Error wraps err in a ParseError for the current line, or returns nil if err is nil
+func (r *Reader) Error(expected string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ParseError{
+		Line:     r.line,
+		Text:     r.Text(),
+		Expected: expected,
+		Err:      err,
+	}
+}
+
+// Errorf returns a ParseError for the current line
+func (r *Reader) Errorf(expected, format string, args ...interface{}) error {
+	return r.Error(expected, fmt.Errorf(format, args...))
+}
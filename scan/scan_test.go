@@ -0,0 +1,65 @@
+package scan
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSscanf(t *testing.T) {
+	for i, tt := range []struct {
+		s       string
+		format  string
+		wantErr bool
+	}{
+		{s: "+4", format: "%d"},
+		{s: "seti 1 8 1 ", format: "%s %d %d %d"},
+		{s: "position=< -9767,  50146>", format: "position=<%d,%d>"},
+		{s: "", format: "%d", wantErr: true},
+		{s: "4x", format: "%d", wantErr: true},
+		{s: "1 2", format: "%d", wantErr: true},
+		{s: "x=1, y=2", format: "x=%d, y=%d..%d", wantErr: true},
+	} {
+		var a, b, c int
+		var str string
+		var err error
+		switch tt.format {
+		case "%d":
+			err = Sscanf(tt.s, tt.format, &a)
+		case "%s %d %d %d":
+			err = Sscanf(tt.s, tt.format, &str, &a, &b, &c)
+		case "position=<%d,%d>":
+			err = Sscanf(tt.s, tt.format, &a, &b)
+		default:
+			err = Sscanf(tt.s, tt.format, &a, &b, &c)
+		}
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%d): got err %v want error %t", i, err, tt.wantErr)
+		}
+	}
+}
+
+func TestReader(t *testing.T) {
+	r := NewReader("1\n2\nthree\n")
+	sum := 0
+	var err error
+	for r.Scan() {
+		var n int
+		if err = r.Scanf("%d", &n); err != nil {
+			break
+		}
+		sum += n
+	}
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("got %v want ParseError", err)
+	}
+	if perr.Line != 3 || perr.Text != "three" || perr.Expected != "%d" {
+		t.Errorf("got %+v", perr)
+	}
+	if r.Scan() || r.Scan() {
+		t.Errorf("trailing newline should not produce an extra line")
+	}
+	if err := r.Next("%d"); err == nil {
+		t.Errorf("reading past the end should fail")
+	}
+}